@@ -0,0 +1,284 @@
+package limi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sanekee/limi/internal/limi"
+)
+
+// Condition is a request condition of a route, evaluated after the route's path and method are matched.
+// Routes added with the same path and method are selected by their conditions,
+// routes with conditions are evaluated in the order they were added, before the route without conditions.
+type Condition struct {
+	name   string
+	match  func(req *http.Request) bool
+	status int // response status when the condition is not matched
+	err    error
+}
+
+// Header returns a Condition matching requests with the header key.
+// When value is not empty, one of the header values must equal to value.
+func Header(key, value string) Condition {
+	key = http.CanonicalHeaderKey(strings.TrimSpace(key))
+	c := Condition{
+		name:   "header " + key,
+		status: http.StatusNotFound,
+		match: func(req *http.Request) bool {
+			values, ok := req.Header[key]
+			if !ok {
+				return false
+			}
+			if value == "" {
+				return true
+			}
+			for _, v := range values {
+				if strings.TrimSpace(v) == value {
+					return true
+				}
+			}
+			return false
+		},
+	}
+	if key == "" {
+		c.err = fmt.Errorf("missing header key %w", limi.ErrInvalidInput)
+	}
+	return c
+}
+
+// Query returns a Condition matching requests with the url query key.
+// When value is not empty, one of the query values must equal to value.
+func Query(key, value string) Condition {
+	c := Condition{
+		name:   "query " + key,
+		status: http.StatusNotFound,
+		match: func(req *http.Request) bool {
+			values, ok := req.URL.Query()[key]
+			if !ok {
+				return false
+			}
+			if value == "" {
+				return true
+			}
+			for _, v := range values {
+				if v == value {
+					return true
+				}
+			}
+			return false
+		},
+	}
+	if key == "" {
+		c.err = fmt.Errorf("missing query key %w", limi.ErrInvalidInput)
+	}
+	return c
+}
+
+// Scheme returns a Condition matching requests with one of the schemes (i.e. `http`, `https`).
+// Request scheme is `https` when the request is received over TLS, otherwise `http`.
+func Scheme(schemes ...string) Condition {
+	c := Condition{
+		name:   "scheme",
+		status: http.StatusNotFound,
+	}
+
+	set := make(map[string]struct{}, len(schemes))
+	for _, s := range schemes {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s != "http" && s != "https" {
+			c.err = fmt.Errorf("unsupported scheme %q %w", s, limi.ErrInvalidInput)
+		}
+		set[s] = struct{}{}
+	}
+	if len(set) == 0 {
+		c.err = fmt.Errorf("missing scheme %w", limi.ErrInvalidInput)
+	}
+
+	c.match = func(req *http.Request) bool {
+		_, ok := set[requestScheme(req)]
+		return ok
+	}
+	return c
+}
+
+// Accept returns a Condition matching requests accepting one of the media types (i.e. `application/vnd.example.v2+json`).
+// Requests without the Accept header accept all media types.
+// Requests not accepting any of the media types are responded with 406 Not Acceptable.
+func Accept(mediaTypes ...string) Condition {
+	c := Condition{
+		name:   "accept",
+		status: http.StatusNotAcceptable,
+	}
+
+	mts, err := parseMediaTypes(mediaTypes)
+	if err != nil {
+		c.err = err
+	}
+
+	c.match = func(req *http.Request) bool {
+		accept := strings.Join(req.Header.Values("Accept"), ",")
+		if accept == "" {
+			return true
+		}
+
+		ranges := limi.ParseAccept(accept)
+		for _, mt := range mts {
+			if limi.AcceptQuality(ranges, mt) > 0 {
+				return true
+			}
+		}
+		return false
+	}
+	return c
+}
+
+// ContentType returns a Condition matching requests with one of the content types, media ranges (i.e. `text/*`) are supported.
+// Requests with other or without content type are responded with 415 Unsupported Media Type.
+func ContentType(mediaTypes ...string) Condition {
+	c := Condition{
+		name:   "content type",
+		status: http.StatusUnsupportedMediaType,
+	}
+
+	mts, err := parseMediaTypes(mediaTypes)
+	if err != nil {
+		c.err = err
+	}
+
+	c.match = func(req *http.Request) bool {
+		ct, ok := limi.ParseMediaRange(req.Header.Get("Content-Type"))
+		if !ok {
+			return false
+		}
+
+		for _, mt := range mts {
+			if mt.Match(ct) {
+				return true
+			}
+		}
+		return false
+	}
+	return c
+}
+
+// Match returns true when the request matches the condition.
+func (c Condition) Match(req *http.Request) bool {
+	return c.match(req)
+}
+
+// String returns the name of the condition.
+func (c Condition) String() string {
+	return c.name
+}
+
+// validateConditions returns the first invalid condition error.
+func validateConditions(conds []Condition) error {
+	for _, c := range conds {
+		if c.err != nil {
+			return fmt.Errorf("invalid %s condition %w", c.name, c.err)
+		}
+		if c.match == nil {
+			return fmt.Errorf("invalid condition %w", limi.ErrInvalidInput)
+		}
+	}
+	return nil
+}
+
+// matchConditions returns true when the request matches all conditions,
+// otherwise returns false with the response status of the unmatched condition.
+func matchConditions(conds []Condition, req *http.Request) (bool, int) {
+	for _, c := range conds {
+		if !c.match(req) {
+			return false, c.status
+		}
+	}
+	return true, 0
+}
+
+// conditionsFromTag returns the conditions declared in the handler's limi struct tag.
+//   - `accept=application/json,application/xml` - Accept condition.
+//   - `contenttype=application/json` - ContentType condition.
+//   - `header=X-Version=2,X-Api-Key` - Header conditions, with or without value.
+//   - `query=format=csv` - Query conditions, with or without value.
+//   - `scheme=https` - Scheme condition.
+func conditionsFromTag(ht handlerTag) []Condition {
+	var conds []Condition
+	if v, ok := ht[tagScheme]; ok {
+		conds = append(conds, Scheme(v...))
+	}
+	for _, v := range ht[tagHeader] {
+		key, value, _ := strings.Cut(v, "=")
+		conds = append(conds, Header(key, strings.TrimSpace(value)))
+	}
+	for _, v := range ht[tagQuery] {
+		key, value, _ := strings.Cut(v, "=")
+		conds = append(conds, Query(strings.TrimSpace(key), strings.TrimSpace(value)))
+	}
+	if v, ok := ht[tagContentType]; ok {
+		conds = append(conds, ContentType(v...))
+	}
+	if v, ok := ht[tagAccept]; ok {
+		conds = append(conds, Accept(v...))
+	}
+	return conds
+}
+
+// conditionsMiddleware returns a middleware responding with the unmatched condition's status.
+func conditionsMiddleware(conds []Condition, notFoundHandler http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if ok, status := matchConditions(conds, req); !ok {
+				conditionFailedHandler(req.Context(), status, notFoundHandler).ServeHTTP(w, req)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// conditionFailedHandler returns the handler responding to a request not matching route conditions.
+// The not found handler of the router dispatching the request is used, falling back to notFoundHandler.
+func conditionFailedHandler(ctx context.Context, status int, notFoundHandler http.Handler) http.Handler {
+	if status == http.StatusNotFound {
+		if h := limi.GetNotFoundHandler(ctx); h != nil {
+			return h
+		}
+		if notFoundHandler != nil {
+			return notFoundHandler
+		}
+		return http.NotFoundHandler()
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, http.StatusText(status), status)
+	})
+}
+
+// parseMediaTypes parses a list of media types.
+func parseMediaTypes(mediaTypes []string) ([]limi.MediaRange, error) {
+	if len(mediaTypes) == 0 {
+		return nil, fmt.Errorf("missing media type %w", limi.ErrInvalidInput)
+	}
+
+	mts := make([]limi.MediaRange, 0, len(mediaTypes))
+	for _, s := range mediaTypes {
+		mt, ok := limi.ParseMediaRange(s)
+		if !ok {
+			return nil, fmt.Errorf("invalid media type %q %w", s, limi.ErrInvalidInput)
+		}
+		mts = append(mts, mt)
+	}
+	return mts, nil
+}
+
+// requestScheme returns the scheme of the request.
+func requestScheme(req *http.Request) string {
+	if req.TLS != nil {
+		return "https"
+	}
+	if req.URL != nil && req.URL.Scheme != "" {
+		return strings.ToLower(req.URL.Scheme)
+	}
+	return "http"
+}
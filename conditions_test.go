@@ -0,0 +1,219 @@
+package limi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sanekee/limi/internal/testing/handler"
+	"github.com/sanekee/limi/internal/testing/require"
+)
+
+func TestConditions(t *testing.T) {
+	newRequest := func(url string, headers map[string]string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		return req
+	}
+
+	type test struct {
+		testName string
+		cond     Condition
+		req      *http.Request
+		expected bool
+	}
+
+	tests := []test{
+		{testName: "header", cond: Header("X-Api-Key", ""), req: newRequest("/", map[string]string{"X-Api-Key": "abc"}), expected: true},
+		{testName: "header missing", cond: Header("X-Api-Key", ""), req: newRequest("/", nil), expected: false},
+		{testName: "header value", cond: Header("x-version", "2"), req: newRequest("/", map[string]string{"X-Version": "2"}), expected: true},
+		{testName: "header different value", cond: Header("X-Version", "2"), req: newRequest("/", map[string]string{"X-Version": "1"}), expected: false},
+		{testName: "query", cond: Query("format", ""), req: newRequest("/?format", nil), expected: true},
+		{testName: "query value", cond: Query("format", "csv"), req: newRequest("/?format=csv", nil), expected: true},
+		{testName: "query different value", cond: Query("format", "csv"), req: newRequest("/?format=json", nil), expected: false},
+		{testName: "scheme https", cond: Scheme("https"), req: newRequest("https://example.com/", nil), expected: true},
+		{testName: "scheme http", cond: Scheme("https"), req: newRequest("http://example.com/", nil), expected: false},
+		{testName: "accept", cond: Accept("application/vnd.x.v2+json"), req: newRequest("/", map[string]string{"Accept": "application/vnd.x.v2+json"}), expected: true},
+		{testName: "accept wildcard", cond: Accept("application/json"), req: newRequest("/", map[string]string{"Accept": "text/html, */*;q=0.1"}), expected: true},
+		{testName: "accept missing", cond: Accept("application/json"), req: newRequest("/", nil), expected: true},
+		{testName: "accept not acceptable", cond: Accept("application/json"), req: newRequest("/", map[string]string{"Accept": "application/json;q=0, text/html"}), expected: false},
+		{testName: "content type", cond: ContentType("application/json"), req: newRequest("/", map[string]string{"Content-Type": "application/json; charset=utf-8"}), expected: true},
+		{testName: "content type range", cond: ContentType("text/*"), req: newRequest("/", map[string]string{"Content-Type": "text/csv"}), expected: true},
+		{testName: "content type missing", cond: ContentType("application/json"), req: newRequest("/", nil), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			require.NoError(t, validateConditions([]Condition{tt.cond}))
+			require.Equal(t, tt.expected, tt.cond.Match(tt.req))
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		require.Error(t, validateConditions([]Condition{Scheme("ftp")}))
+		require.Error(t, validateConditions([]Condition{Accept("json")}))
+		require.Error(t, validateConditions([]Condition{ContentType()}))
+		require.Error(t, validateConditions([]Condition{Header("", "")}))
+		require.Error(t, validateConditions([]Condition{{}}))
+	})
+}
+
+type testConditionV1 struct {
+	_ struct{} `limi:"path=/items,accept=application/vnd.x.v1+json"`
+}
+
+func (t testConditionV1) Get(w http.ResponseWriter, req *http.Request) {
+	w.Write([]byte("v1")) // nolint:errcheck
+}
+
+type testConditionV2 struct {
+	_ struct{} `limi:"path=/items,accept=application/vnd.x.v2+json,scheme=https"`
+}
+
+func (t testConditionV2) Get(w http.ResponseWriter, req *http.Request) {
+	w.Write([]byte("v2")) // nolint:errcheck
+}
+
+type testConditionInvalid struct {
+	_ struct{} `limi:"path=/items,scheme=ftp"`
+}
+
+func (t testConditionInvalid) Get(w http.ResponseWriter, req *http.Request) {}
+
+func TestRouteConditions(t *testing.T) {
+	serve := func(h http.Handler, method, url string, headers map[string]string) (int, string) {
+		req := httptest.NewRequest(method, url, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		body, err := io.ReadAll(rec.Body)
+		require.NoError(t, err)
+		return rec.Result().StatusCode, string(body)
+	}
+
+	t.Run("struct tag", func(t *testing.T) {
+		r, err := NewRouter("/")
+		require.NoError(t, err)
+
+		err = r.AddHandlers([]Handler{testConditionV1{}, testConditionV2{}})
+		require.NoError(t, err)
+
+		status, body := serve(r, http.MethodGet, "http://example.com/items", map[string]string{"Accept": "application/vnd.x.v1+json"})
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "v1", body)
+
+		status, body = serve(r, http.MethodGet, "https://example.com/items", map[string]string{"Accept": "application/vnd.x.v2+json"})
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "v2", body)
+
+		status, _ = serve(r, http.MethodGet, "http://example.com/items", map[string]string{"Accept": "application/vnd.x.v3+json"})
+		require.Equal(t, http.StatusNotAcceptable, status)
+
+		status, _ = serve(r, http.MethodPost, "http://example.com/items", nil)
+		require.Equal(t, http.StatusMethodNotAllowed, status)
+	})
+
+	t.Run("invalid struct tag", func(t *testing.T) {
+		r, err := NewRouter("/")
+		require.NoError(t, err)
+
+		err = r.AddHandler(testConditionInvalid{})
+		require.Error(t, err)
+	})
+
+	t.Run("when", func(t *testing.T) {
+		r, err := NewRouter("/")
+		require.NoError(t, err)
+
+		err = r.When(Query("format", "csv")).Get("/report", handler.NewHandlerFunc(http.StatusOK, nil, []byte("csv")))
+		require.NoError(t, err)
+
+		err = r.When(ContentType("application/json")).Post("/report", handler.NewHandlerFunc(http.StatusCreated, nil, nil))
+		require.NoError(t, err)
+
+		status, body := serve(r, http.MethodGet, "/report?format=csv", nil)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "csv", body)
+
+		status, _ = serve(r, http.MethodGet, "/report", nil)
+		require.Equal(t, http.StatusNotFound, status)
+
+		status, _ = serve(r, http.MethodPost, "/report", map[string]string{"Content-Type": "application/json"})
+		require.Equal(t, http.StatusCreated, status)
+
+		status, _ = serve(r, http.MethodPost, "/report", map[string]string{"Content-Type": "text/plain"})
+		require.Equal(t, http.StatusUnsupportedMediaType, status)
+	})
+
+	t.Run("default handler", func(t *testing.T) {
+		r, err := NewRouter("/")
+		require.NoError(t, err)
+
+		err = r.Get("/report", handler.NewHandlerFunc(http.StatusOK, nil, []byte("json")))
+		require.NoError(t, err)
+
+		err = r.When(Query("format", "csv")).Get("/report", handler.NewHandlerFunc(http.StatusOK, nil, []byte("csv")))
+		require.NoError(t, err)
+
+		err = r.Get("/report", handler.NewHandlerFunc(http.StatusOK, nil, []byte("json")))
+		require.Error(t, err)
+
+		status, body := serve(r, http.MethodGet, "/report?format=csv", nil)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "csv", body)
+
+		status, body = serve(r, http.MethodGet, "/report", nil)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "json", body)
+	})
+
+	t.Run("group", func(t *testing.T) {
+		r, err := NewRouter("/", WithNotFoundHandler(handler.NewHandlerFunc(http.StatusNotFound, nil, []byte("not found"))))
+		require.NoError(t, err)
+
+		err = r.Group(func(g *Group) error {
+			g = g.When(Scheme("https"), Header("X-Api-Key", ""))
+			return g.AddHTTPHandler("/static", handler.NewHandlerFunc(http.StatusOK, nil, []byte("static")))
+		})
+		require.NoError(t, err)
+
+		status, body := serve(r, http.MethodGet, "https://example.com/static", map[string]string{"X-Api-Key": "abc"})
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "static", body)
+
+		status, body = serve(r, http.MethodGet, "http://example.com/static", map[string]string{"X-Api-Key": "abc"})
+		require.Equal(t, http.StatusNotFound, status)
+		require.Equal(t, "not found", body)
+	})
+
+	t.Run("subrouter", func(t *testing.T) {
+		r, err := NewRouter("/", WithNotFoundHandler(handler.NewHandlerFunc(http.StatusTeapot, nil, []byte("not found"))))
+		require.NoError(t, err)
+
+		sr, err := r.AddRouter("/api")
+		require.NoError(t, err)
+
+		err = sr.When(Query("format", "csv")).Get("/x", handler.NewHandlerFunc(http.StatusOK, nil, []byte("csv")))
+		require.NoError(t, err)
+
+		err = sr.When(Query("format", "csv")).AddHTTPHandler("/static", handler.NewHandlerFunc(http.StatusOK, nil, []byte("static")))
+		require.NoError(t, err)
+
+		// the parent's not found handler is used for unknown paths and failed conditions
+		for _, url := range []string{"/api/y", "/api/x", "/api/static"} {
+			status, body := serve(r, http.MethodGet, url, nil)
+			require.Equal(t, http.StatusTeapot, status)
+			require.Equal(t, "not found", body)
+		}
+
+		status, body := serve(r, http.MethodGet, "/api/x?format=csv", nil)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "csv", body)
+	})
+}
@@ -12,6 +12,11 @@ func GetURLParam(ctx context.Context, key string) string {
 	return limi.GetURLParam(ctx, key)
 }
 
+// HostParam get value set by label matched in host
+func HostParam(ctx context.Context, key string) string {
+	return limi.GetHostParam(ctx, key)
+}
+
 // GetURLParam set data to the value set by label matched in url
 func ParseURLParam(ctx context.Context, key string, data any) error {
 	return limi.ParseURLParam(ctx, key, data)
@@ -22,6 +27,21 @@ func SetParamsData(ctx context.Context, data any) error {
 	return limi.SetParamsData(ctx, data)
 }
 
+// RoutePattern get the matched route's path pattern, i.e. `/teams/{id}`
+func RoutePattern(ctx context.Context) string {
+	return limi.GetRoutePattern(ctx)
+}
+
+// AllowedMethods get the matched route's allowed methods, set for OPTIONS requests and requests with a method not allowed
+func AllowedMethods(ctx context.Context) []string {
+	return limi.GetAllowedMethods(ctx)
+}
+
+// GetRouteMetadata get the matched route's metadata value by key
+func GetRouteMetadata(ctx context.Context, key string) (any, bool) {
+	return limi.GetRouteMetadata(ctx, key)
+}
+
 // GetParams get context params parsed from URL
 func GetParams[T any](ctx context.Context) (T, error) {
 	var ret T
@@ -0,0 +1,313 @@
+package limi
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/sanekee/limi/internal/limi"
+	"github.com/sanekee/limi/render"
+)
+
+const (
+	defaultPageLimit = 20
+	defaultMaxLimit  = 100
+
+	queryOffset = "offset"
+	queryLimit  = "limit"
+)
+
+// Page is a page of the items listed by Resource.
+type Page[T any] struct {
+	XMLName xml.Name `json:"-" xml:"page"`
+	Items   []T      `json:"items" xml:"items>item"`
+	Total   int      `json:"total" xml:"total"`
+	Offset  int      `json:"offset" xml:"offset"`
+	Limit   int      `json:"limit" xml:"limit"`
+}
+
+// Validator is implemented by items validating themselves,
+// invalid items are responded with 422 Unprocessable Entity by Resource.
+type Validator interface {
+	Validate() error
+}
+
+// Resource is a resource handler serving the REST endpoints of a Store, added with AddResource.
+//   - `GET /teams?offset=0&limit=20&name=foo` - lists a Page of the items, queries other than offset and limit are the filters.
+//   - `POST /teams` - creates an item, responded with 201 Created and the item's `Location` header.
+//   - `GET /teams/{id}` - gets an item.
+//   - `PUT /teams/{id}` - replaces an item.
+//   - `PATCH /teams/{id}` - updates the fields of an item present in the request body.
+//   - `DELETE /teams/{id}` - deletes an item, responded with 204 No Content.
+//
+// Request bodies are bound by the content type (`application/json` or `application/xml`), responses are encoded with Respond.
+// Errors are responded with render.Problem, ErrNotFound with 404 Not Found and ErrConflict with 409 Conflict.
+type Resource[T any, ID comparable] struct {
+	// Store is the storage of the items.
+	Store Store[T, ID]
+	// ItemID returns the id of the item, used in the `Location` header of the created item.
+	ItemID func(item T) ID
+	// Filters is the list of queries allowed as filters, all queries are allowed when not set.
+	Filters []string
+	// Limit is the default page size, default is 20.
+	Limit int
+	// MaxLimit is the maximum page size, default is 100.
+	MaxLimit int
+}
+
+// NewResource returns a Resource of the store.
+func NewResource[T any, ID comparable](store Store[T, ID], itemID func(item T) ID) Resource[T, ID] {
+	return Resource[T, ID]{
+		Store:    store,
+		ItemID:   itemID,
+		Limit:    defaultPageLimit,
+		MaxLimit: defaultMaxLimit,
+	}
+}
+
+// List lists a page of the items.
+func (rs Resource[T, ID]) List(w http.ResponseWriter, req *http.Request) {
+	opts, err := rs.listOptions(req.URL.Query())
+	if err != nil {
+		respondError(w, req, err)
+		return
+	}
+
+	items, total, err := rs.Store.List(req.Context(), opts)
+	if err != nil {
+		respondError(w, req, err)
+		return
+	}
+	if items == nil {
+		items = []T{}
+	}
+
+	Respond(w, req, http.StatusOK, Page[T]{ // nolint:errcheck
+		Items:  items,
+		Total:  total,
+		Offset: opts.Offset,
+		Limit:  opts.Limit,
+	})
+}
+
+// Create creates an item.
+func (rs Resource[T, ID]) Create(w http.ResponseWriter, req *http.Request) {
+	var item T
+	if err := bindItem(req, &item); err != nil {
+		respondError(w, req, err)
+		return
+	}
+
+	created, err := rs.Store.Create(req.Context(), item)
+	if err != nil {
+		respondError(w, req, err)
+		return
+	}
+
+	if rs.ItemID != nil {
+		w.Header().Set("Location", path.Join(req.URL.Path, url.PathEscape(fmt.Sprint(rs.ItemID(created)))))
+	}
+	Respond(w, req, http.StatusCreated, created) // nolint:errcheck
+}
+
+// Show gets an item.
+func (rs Resource[T, ID]) Show(w http.ResponseWriter, req *http.Request) {
+	id, err := parseResourceID[ID](req)
+	if err != nil {
+		respondError(w, req, err)
+		return
+	}
+
+	item, err := rs.Store.Get(req.Context(), id)
+	if err != nil {
+		respondError(w, req, err)
+		return
+	}
+	Respond(w, req, http.StatusOK, item) // nolint:errcheck
+}
+
+// Update replaces an item.
+func (rs Resource[T, ID]) Update(w http.ResponseWriter, req *http.Request) {
+	id, err := parseResourceID[ID](req)
+	if err != nil {
+		respondError(w, req, err)
+		return
+	}
+
+	var item T
+	if err := bindItem(req, &item); err != nil {
+		respondError(w, req, err)
+		return
+	}
+
+	updated, err := rs.Store.Update(req.Context(), id, item)
+	if err != nil {
+		respondError(w, req, err)
+		return
+	}
+	Respond(w, req, http.StatusOK, updated) // nolint:errcheck
+}
+
+// Patch updates the fields of an item present in the request body.
+func (rs Resource[T, ID]) Patch(w http.ResponseWriter, req *http.Request) {
+	id, err := parseResourceID[ID](req)
+	if err != nil {
+		respondError(w, req, err)
+		return
+	}
+
+	item, err := rs.Store.Get(req.Context(), id)
+	if err != nil {
+		respondError(w, req, err)
+		return
+	}
+
+	if err := bindItem(req, &item); err != nil {
+		respondError(w, req, err)
+		return
+	}
+
+	updated, err := rs.Store.Update(req.Context(), id, item)
+	if err != nil {
+		respondError(w, req, err)
+		return
+	}
+	Respond(w, req, http.StatusOK, updated) // nolint:errcheck
+}
+
+// Delete deletes an item.
+func (rs Resource[T, ID]) Delete(w http.ResponseWriter, req *http.Request) {
+	id, err := parseResourceID[ID](req)
+	if err != nil {
+		respondError(w, req, err)
+		return
+	}
+
+	if err := rs.Store.Delete(req.Context(), id); err != nil {
+		respondError(w, req, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listOptions returns the list options of the url queries.
+func (rs Resource[T, ID]) listOptions(queries url.Values) (ListOptions, error) {
+	limit, maxLimit := rs.Limit, rs.MaxLimit
+	if maxLimit <= 0 {
+		maxLimit = defaultMaxLimit
+	}
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+
+	opts := ListOptions{
+		Filters: url.Values{},
+		Limit:   limit,
+	}
+
+	for key, values := range queries {
+		switch key {
+		case queryOffset:
+			offset, err := strconv.Atoi(values[0])
+			if err != nil || offset < 0 {
+				return opts, render.NewProblem(http.StatusBadRequest, "invalid offset "+values[0])
+			}
+			opts.Offset = offset
+		case queryLimit:
+			limit, err := strconv.Atoi(values[0])
+			if err != nil || limit <= 0 {
+				return opts, render.NewProblem(http.StatusBadRequest, "invalid limit "+values[0])
+			}
+			if limit > maxLimit {
+				limit = maxLimit
+			}
+			opts.Limit = limit
+		default:
+			if rs.Filters != nil && !contains(rs.Filters, key) {
+				continue
+			}
+			opts.Filters[key] = values
+		}
+	}
+	return opts, nil
+}
+
+// parseResourceID returns the resource id of the request.
+func parseResourceID[ID comparable](req *http.Request) (ID, error) {
+	var id ID
+	param := limi.GetResourceIDParam(req.Context())
+	if err := limi.ParseURLParam(req.Context(), param, &id); err != nil {
+		return id, render.NewProblem(http.StatusBadRequest, "invalid id "+limi.GetURLParam(req.Context(), param))
+	}
+	return id, nil
+}
+
+// bindItem decodes the request body into item by the content type, and validates item when it's a Validator.
+func bindItem(req *http.Request, item any) error {
+	mt := "application/json"
+	if ct := req.Header.Get("Content-Type"); ct != "" {
+		var err error
+		if mt, _, err = mime.ParseMediaType(ct); err != nil {
+			return render.NewProblem(http.StatusUnsupportedMediaType, "invalid content type "+ct)
+		}
+	}
+
+	var err error
+	switch {
+	case mt == "application/json" || strings.HasSuffix(mt, "+json"):
+		err = json.NewDecoder(req.Body).Decode(item)
+	case mt == "application/xml" || mt == "text/xml" || strings.HasSuffix(mt, "+xml"):
+		err = xml.NewDecoder(req.Body).Decode(item)
+	default:
+		return render.NewProblem(http.StatusUnsupportedMediaType, "unsupported content type "+mt)
+	}
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return render.NewProblem(http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxBytesErr.Limit))
+	}
+	if err != nil {
+		return render.NewProblem(http.StatusBadRequest, "invalid request body")
+	}
+
+	if v, ok := item.(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return render.NewProblem(http.StatusUnprocessableEntity, err.Error())
+		}
+	}
+	return nil
+}
+
+// respondError responds the error with render.Problem.
+func respondError(w http.ResponseWriter, req *http.Request, err error) {
+	var p render.Problem
+	switch {
+	case errors.As(err, &p):
+	case errors.Is(err, ErrNotFound):
+		p = render.NewProblem(http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrConflict):
+		p = render.NewProblem(http.StatusConflict, err.Error())
+	default:
+		p = render.NewProblem(http.StatusInternalServerError, "")
+	}
+	render.WriteProblem(w, req, p) // nolint:errcheck
+}
+
+// contains returns true when values contains value.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
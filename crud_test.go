@@ -0,0 +1,200 @@
+package limi
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/sanekee/limi/internal/testing/require"
+)
+
+type testTeam struct {
+	ID   int    `json:"id" xml:"id"`
+	Name string `json:"name" xml:"name"`
+	Tier string `json:"tier,omitempty" xml:"tier,omitempty"`
+}
+
+func (t testTeam) Validate() error {
+	if t.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+func newTestTeamStore() *MemStore[testTeam, int] {
+	var seq int
+	store := NewMemStore(
+		func() int {
+			seq++
+			return seq
+		},
+		func(t testTeam, id int) testTeam {
+			t.ID = id
+			return t
+		},
+	)
+	store.Filter = func(t testTeam, filters url.Values) bool {
+		return filters.Get("name") == "" || filters.Get("name") == t.Name
+	}
+	return store
+}
+
+func TestResource(t *testing.T) {
+	newRouter := func(t *testing.T) *Router {
+		r, err := NewRouter("/api", WithResourceID("{id:[0-9]+}"))
+		require.NoError(t, err)
+
+		res := NewResource[testTeam, int](newTestTeamStore(), func(t testTeam) int { return t.ID })
+		res.Limit = 2
+		require.NoError(t, r.AddResource("/teams", res))
+		return r
+	}
+
+	serve := func(r http.Handler, method string, url string, contentType string, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	decode := func(t *testing.T, rec *httptest.ResponseRecorder, v any) {
+		body, err := io.ReadAll(rec.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, v))
+	}
+
+	t.Run("create and show", func(t *testing.T) {
+		r := newRouter(t)
+
+		rec := serve(r, http.MethodPost, "/api/teams", "application/json", `{"name":"foo"}`)
+		require.Equal(t, http.StatusCreated, rec.Code)
+		require.Equal(t, "/api/teams/1", rec.Header().Get("Location"))
+
+		var team testTeam
+		decode(t, rec, &team)
+		require.Equal(t, testTeam{ID: 1, Name: "foo"}, team)
+
+		rec = serve(r, http.MethodGet, "/api/teams/1", "", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		decode(t, rec, &team)
+		require.Equal(t, testTeam{ID: 1, Name: "foo"}, team)
+	})
+
+	t.Run("create xml", func(t *testing.T) {
+		r := newRouter(t)
+
+		rec := serve(r, http.MethodPost, "/api/teams", "application/xml", `<testTeam><name>foo</name></testTeam>`)
+		require.Equal(t, http.StatusCreated, rec.Code)
+	})
+
+	t.Run("list with pagination and filters", func(t *testing.T) {
+		r := newRouter(t)
+		for _, name := range []string{"foo", "bar", "foo", "foo"} {
+			rec := serve(r, http.MethodPost, "/api/teams", "", `{"name":"`+name+`"}`)
+			require.Equal(t, http.StatusCreated, rec.Code)
+		}
+
+		rec := serve(r, http.MethodGet, "/api/teams", "", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var page Page[testTeam]
+		decode(t, rec, &page)
+		require.Equal(t, 4, page.Total)
+		require.Equal(t, 2, page.Limit)
+		require.Equal(t, []testTeam{{ID: 1, Name: "foo"}, {ID: 2, Name: "bar"}}, page.Items)
+
+		rec = serve(r, http.MethodGet, "/api/teams?name=foo&offset=1&limit=5", "", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		page = Page[testTeam]{}
+		decode(t, rec, &page)
+		require.Equal(t, 3, page.Total)
+		require.Equal(t, 1, page.Offset)
+		require.Equal(t, []testTeam{{ID: 3, Name: "foo"}, {ID: 4, Name: "foo"}}, page.Items)
+
+		rec = serve(r, http.MethodGet, "/api/teams?offset=-1", "", "")
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		require.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+	})
+
+	t.Run("update and patch", func(t *testing.T) {
+		r := newRouter(t)
+		rec := serve(r, http.MethodPost, "/api/teams", "", `{"name":"foo","tier":"gold"}`)
+		require.Equal(t, http.StatusCreated, rec.Code)
+
+		rec = serve(r, http.MethodPatch, "/api/teams/1", "application/merge-patch+json", `{"name":"bar"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var team testTeam
+		decode(t, rec, &team)
+		require.Equal(t, testTeam{ID: 1, Name: "bar", Tier: "gold"}, team)
+
+		rec = serve(r, http.MethodPut, "/api/teams/1", "", `{"id":5,"name":"baz"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		team = testTeam{}
+		decode(t, rec, &team)
+		require.Equal(t, testTeam{ID: 1, Name: "baz"}, team)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		r := newRouter(t)
+		rec := serve(r, http.MethodPost, "/api/teams", "", `{"name":"foo"}`)
+		require.Equal(t, http.StatusCreated, rec.Code)
+
+		rec = serve(r, http.MethodDelete, "/api/teams/1", "", "")
+		require.Equal(t, http.StatusNoContent, rec.Code)
+
+		rec = serve(r, http.MethodGet, "/api/teams/1", "", "")
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("body too large", func(t *testing.T) {
+		r := newRouter(t)
+		limit := func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				req.Body = http.MaxBytesReader(w, req.Body, 4)
+				next.ServeHTTP(w, req)
+			})
+		}
+
+		rec := serve(limit(r), http.MethodPost, "/api/teams", "", `{"name":"foo"}`)
+		require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+		require.True(t, strings.Contains(rec.Body.String(), "request body exceeds 4 bytes"))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		r := newRouter(t)
+
+		type test struct {
+			testName       string
+			method         string
+			url            string
+			contentType    string
+			body           string
+			expectedStatus int
+		}
+		tests := []test{
+			{testName: "not found", method: http.MethodGet, url: "/api/teams/9", expectedStatus: http.StatusNotFound},
+			{testName: "update not found", method: http.MethodPut, url: "/api/teams/9", body: `{"name":"foo"}`, expectedStatus: http.StatusNotFound},
+			{testName: "invalid body", method: http.MethodPost, url: "/api/teams", body: `{"name":`, expectedStatus: http.StatusBadRequest},
+			{testName: "unsupported content type", method: http.MethodPost, url: "/api/teams", contentType: "text/plain", body: "foo", expectedStatus: http.StatusUnsupportedMediaType},
+			{testName: "validation", method: http.MethodPost, url: "/api/teams", body: `{}`, expectedStatus: http.StatusUnprocessableEntity},
+		}
+		for _, tt := range tests {
+			t.Run(tt.testName, func(t *testing.T) {
+				rec := serve(r, tt.method, tt.url, tt.contentType, tt.body)
+				require.Equal(t, tt.expectedStatus, rec.Code)
+				require.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+
+				var problem map[string]any
+				decode(t, rec, &problem)
+				require.Equal(t, float64(tt.expectedStatus), problem["status"])
+			})
+		}
+	})
+}
@@ -29,13 +29,7 @@ func (m Merchants) Get(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	body, err := json.Marshal(l)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-	w.WriteHeader(http.StatusOK)
-	w.Write(body) // nolint:errcheck
+	limi.Respond(w, req, http.StatusOK, l) // nolint:errcheck
 }
 
 func (m Merchants) Post(w http.ResponseWriter, req *http.Request) {
@@ -57,14 +51,7 @@ func (m Merchants) Post(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	mBody, err := json.Marshal(merchant)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	w.WriteHeader(http.StatusOK)
-	w.Write(mBody) // nolint:errcheck
+	limi.Respond(w, req, http.StatusOK, merchant) // nolint:errcheck
 
 }
 
@@ -90,13 +77,7 @@ func (m Merchant) Get(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	body, err := json.Marshal(merchant)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-	w.WriteHeader(http.StatusOK)
-	w.Write(body) // nolint:errcheck
+	limi.Respond(w, req, http.StatusOK, merchant) // nolint:errcheck
 }
 
 func (m Merchant) Put(w http.ResponseWriter, req *http.Request) {
@@ -124,13 +105,7 @@ func (m Merchant) Put(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	mBody, err := json.Marshal(merchant)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-	w.WriteHeader(http.StatusOK)
-	w.Write(mBody) // nolint:errcheck
+	limi.Respond(w, req, http.StatusOK, merchant) // nolint:errcheck
 
 }
 
@@ -30,13 +30,7 @@ func (t Teams) Get(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	body, err := json.Marshal(l)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-	w.WriteHeader(http.StatusOK)
-	w.Write(body) // nolint:errcheck
+	limi.Respond(w, req, http.StatusOK, l) // nolint:errcheck
 }
 
 func (t Teams) Post(w http.ResponseWriter, req *http.Request) {
@@ -58,14 +52,7 @@ func (t Teams) Post(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	mBody, err := json.Marshal(merchant)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	w.WriteHeader(http.StatusOK)
-	w.Write(mBody) // nolint:errcheck
+	limi.Respond(w, req, http.StatusOK, merchant) // nolint:errcheck
 
 }
 
@@ -91,13 +78,7 @@ func (t Team) Get(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	body, err := json.Marshal(team)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-	w.WriteHeader(http.StatusOK)
-	w.Write(body) // nolint:errcheck
+	limi.Respond(w, req, http.StatusOK, team) // nolint:errcheck
 }
 
 func (t Team) Put(w http.ResponseWriter, req *http.Request) {
@@ -125,13 +106,7 @@ func (t Team) Put(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	mBody, err := json.Marshal(merchant)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-	w.WriteHeader(http.StatusOK)
-	w.Write(mBody) // nolint:errcheck
+	limi.Respond(w, req, http.StatusOK, merchant) // nolint:errcheck
 
 }
 
@@ -172,11 +147,5 @@ func (t TeamMerchants) Get(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	body, err := json.Marshal(merchants)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-	w.WriteHeader(http.StatusOK)
-	w.Write(body) // nolint:errcheck
+	limi.Respond(w, req, http.StatusOK, merchants) // nolint:errcheck
 }
@@ -0,0 +1,192 @@
+package limi
+
+import (
+	"net/http"
+
+	"github.com/sanekee/limi/internal/limi"
+)
+
+// Metadata is the route's metadata, matched route's metadata is retrievable with GetRouteMetadata.
+type Metadata map[string]any
+
+// Route metadata keys read by the limi middlewares.
+const (
+	// MetadataBodyLimit is the request body size limit of the route, an integer or a byte size string (i.e. `10MB`).
+	// Declared with the `bodylimit` struct tag, i.e. `limi:"path=/upload,bodylimit=10MB"`.
+	MetadataBodyLimit = limi.MetadataBodyLimit
+	// MetadataTimeout is the request timeout of the route, a time.Duration or a duration string (i.e. `2s`).
+	// Declared with the `timeout` struct tag, i.e. `limi:"path=/reports,timeout=30s"`.
+	MetadataTimeout = limi.MetadataTimeout
+	// MetadataRateLimit is the rate limit of the route, a middleware.Rate or a rate string (i.e. `100/1m`).
+	// Declared with the `ratelimit` struct tag, i.e. `limi:"path=/payments,ratelimit=100/1m"`.
+	MetadataRateLimit = limi.MetadataRateLimit
+)
+
+// Group is a set of routes sharing the router's tree and path, with additional middlewares and metadata.
+// Group middlewares are executed after the router's middlewares and before the route's middlewares.
+type Group struct {
+	router      *Router
+	middlewares []func(http.Handler) http.Handler
+	metadata    Metadata
+	conditions  []Condition
+	versions    []string
+}
+
+// routeConfig is the configuration of the routes added through a Group.
+type routeConfig struct {
+	metadata   Metadata
+	conditions []Condition
+	versions   []string
+}
+
+// newGroup returns a new Group of the router.
+func newGroup(r *Router) *Group {
+	return &Group{
+		router: r,
+	}
+}
+
+// With returns a new Group with a list of middlewares appended.
+func (g *Group) With(mws ...func(http.Handler) http.Handler) *Group {
+	return &Group{
+		router:      g.router,
+		middlewares: concatMiddlewares(g.middlewares, mws),
+		metadata:    g.metadata,
+		conditions:  g.conditions,
+		versions:    g.versions,
+	}
+}
+
+// WithMetadata returns a new Group with metadata merged.
+func (g *Group) WithMetadata(md Metadata) *Group {
+	return &Group{
+		router:      g.router,
+		middlewares: g.middlewares,
+		metadata:    mergeMetadata(g.metadata, md),
+		conditions:  g.conditions,
+		versions:    g.versions,
+	}
+}
+
+// When returns a new Group with a list of conditions appended, see Condition.
+func (g *Group) When(conds ...Condition) *Group {
+	return &Group{
+		router:      g.router,
+		middlewares: g.middlewares,
+		metadata:    g.metadata,
+		conditions:  append(append([]Condition{}, g.conditions...), conds...),
+		versions:    g.versions,
+	}
+}
+
+// Versions returns a new Group with the API versions of the routes added through it, see Versioning.
+func (g *Group) Versions(versions ...string) *Group {
+	return &Group{
+		router:      g.router,
+		middlewares: g.middlewares,
+		metadata:    g.metadata,
+		conditions:  g.conditions,
+		versions:    versions,
+	}
+}
+
+// Group calls fn with a new Group inheriting the group's middlewares and metadata, returning the error of fn.
+func (g *Group) Group(fn func(g *Group) error) error {
+	return fn(g.With())
+}
+
+// AddHandler adds handler with a list of middlewares, see Router.AddHandler.
+func (g *Group) AddHandler(handler Handler, mws ...func(http.Handler) http.Handler) error {
+	return g.router.addHandler(handler, nil, g.config(), concatMiddlewares(g.middlewares, mws)...)
+}
+
+// AddHandlers adds multiple handlers with a list of middlewares.
+func (g *Group) AddHandlers(handlers []Handler, mws ...func(http.Handler) http.Handler) error {
+	for _, h := range handlers {
+		if err := g.AddHandler(h, mws...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddResource adds a resource handler with the collection path and a list of middlewares, see Router.AddResource.
+func (g *Group) AddResource(path string, handler Handler, mws ...func(http.Handler) http.Handler) error {
+	return g.router.addHandler(handler, []string{path}, g.config(), concatMiddlewares(g.middlewares, mws)...)
+}
+
+// AddHandlerFunc adds http handler with path and method.
+func (g *Group) AddHandlerFunc(path string, method string, fn http.HandlerFunc, mws ...func(http.Handler) http.Handler) error {
+	return g.Methods(path, []string{method}, fn, mws...)
+}
+
+// Methods adds http handler with path for a list of methods.
+func (g *Group) Methods(path string, methods []string, fn http.HandlerFunc, mws ...func(http.Handler) http.Handler) error {
+	return g.router.addHandlerFunc(path, methods, fn, g.config(), concatMiddlewares(g.middlewares, mws)...)
+}
+
+// Get adds http handler with path for GET method.
+func (g *Group) Get(path string, fn http.HandlerFunc, mws ...func(http.Handler) http.Handler) error {
+	return g.AddHandlerFunc(path, http.MethodGet, fn, mws...)
+}
+
+// Post adds http handler with path for POST method.
+func (g *Group) Post(path string, fn http.HandlerFunc, mws ...func(http.Handler) http.Handler) error {
+	return g.AddHandlerFunc(path, http.MethodPost, fn, mws...)
+}
+
+// Put adds http handler with path for PUT method.
+func (g *Group) Put(path string, fn http.HandlerFunc, mws ...func(http.Handler) http.Handler) error {
+	return g.AddHandlerFunc(path, http.MethodPut, fn, mws...)
+}
+
+// Patch adds http handler with path for PATCH method.
+func (g *Group) Patch(path string, fn http.HandlerFunc, mws ...func(http.Handler) http.Handler) error {
+	return g.AddHandlerFunc(path, http.MethodPatch, fn, mws...)
+}
+
+// Delete adds http handler with path for DELETE method.
+func (g *Group) Delete(path string, fn http.HandlerFunc, mws ...func(http.Handler) http.Handler) error {
+	return g.AddHandlerFunc(path, http.MethodDelete, fn, mws...)
+}
+
+// Any adds http handler with path for all standard methods and router's custom methods.
+func (g *Group) Any(path string, fn http.HandlerFunc, mws ...func(http.Handler) http.Handler) error {
+	return g.Methods(path, g.router.allMethods(), fn, mws...)
+}
+
+// AddHTTPHandler adds a catch all http handler with path.
+func (g *Group) AddHTTPHandler(path string, h http.Handler, mws ...func(http.Handler) http.Handler) error {
+	return g.router.addHTTPHandler(path, h, g.config(), concatMiddlewares(g.middlewares, mws)...)
+}
+
+// config returns the route config of the group.
+func (g *Group) config() routeConfig {
+	return routeConfig{
+		metadata:   g.metadata,
+		conditions: g.conditions,
+		versions:   g.versions,
+	}
+}
+
+// mergeMetadata returns a new Metadata with md1 merged into md.
+func mergeMetadata(md Metadata, md1 Metadata) Metadata {
+	ret := make(Metadata, len(md)+len(md1))
+	for k, v := range md {
+		ret[k] = v
+	}
+	for k, v := range md1 {
+		ret[k] = v
+	}
+	return ret
+}
+
+// setMetadata returns a middleware setting the route metadata in context.
+func setMetadata(md Metadata) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			limi.SetRouteMetadata(req.Context(), md)
+			next.ServeHTTP(w, req)
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package limi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sanekee/limi/internal/testing/handler"
+	"github.com/sanekee/limi/internal/testing/handler/foo"
+	"github.com/sanekee/limi/internal/testing/require"
+)
+
+func TestGroup(t *testing.T) {
+	addLayer := func(layers *[]int, layer int) {
+		*layers = append(*layers, layer)
+	}
+
+	newMiddleware := func(layers *[]int, layer int) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				addLayer(layers, layer)
+				next.ServeHTTP(w, req)
+			})
+		}
+	}
+
+	t.Run("with middlewares", func(t *testing.T) {
+		var layers []int
+		r, err := NewRouter("/", WithMiddlewares(newMiddleware(&layers, 1)))
+		require.NoError(t, err)
+
+		err = r.With(newMiddleware(&layers, 2)).
+			AddHandlerFunc("/foo", http.MethodGet, handler.NewHandlerFunc(http.StatusOK, nil, []byte("foo")), newMiddleware(&layers, 3))
+		require.NoError(t, err)
+
+		err = r.AddHandlerFunc("/bar", http.MethodGet, handler.NewHandlerFunc(http.StatusOK, nil, []byte("bar")))
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://localhost:9090/foo", nil)
+
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		body, err := io.ReadAll(rec.Body)
+		require.NoError(t, err)
+		require.Equal(t, "foo", string(body))
+		require.Equal(t, []int{1, 2, 3}, layers)
+
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "http://localhost:9090/bar", nil)
+
+		layers = []int{}
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+		require.Equal(t, []int{1}, layers)
+	})
+
+	t.Run("nested groups with handler", func(t *testing.T) {
+		var layers []int
+		r, err := NewRouter("/", WithMiddlewares(newMiddleware(&layers, 1)))
+		require.NoError(t, err)
+
+		err = r.Group(func(g *Group) error {
+			g = g.With(newMiddleware(&layers, 2))
+			return g.Group(func(g *Group) error {
+				return g.With(newMiddleware(&layers, 3)).AddHandler(foo.Foo{}, newMiddleware(&layers, 4))
+			})
+		})
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://localhost:9090/foo", nil)
+
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		body, err := io.ReadAll(rec.Body)
+		require.NoError(t, err)
+		require.Equal(t, "foo", string(body))
+		require.Equal(t, []int{1, 2, 3, 4}, layers)
+
+		// method not allowed is handled with the same middlewares order
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodDelete, "http://localhost:9090/foo", nil)
+
+		layers = []int{}
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Result().StatusCode)
+		require.Equal(t, []int{1, 2, 3, 4}, layers)
+	})
+
+	t.Run("metadata", func(t *testing.T) {
+		r, err := NewRouter("/")
+		require.NoError(t, err)
+
+		g := r.With().WithMetadata(Metadata{"auth": "admin"})
+		err = g.AddHandlerFunc("/foo", http.MethodGet, func(w http.ResponseWriter, req *http.Request) {
+			v, ok := GetRouteMetadata(req.Context(), "auth")
+			require.True(t, ok)
+			require.Equal(t, "admin", v)
+			w.WriteHeader(http.StatusOK)
+		})
+		require.NoError(t, err)
+
+		err = r.AddHandlerFunc("/foo", http.MethodPost, func(w http.ResponseWriter, req *http.Request) {
+			_, ok := GetRouteMetadata(req.Context(), "auth")
+			require.False(t, ok)
+			w.WriteHeader(http.StatusCreated)
+		})
+		require.NoError(t, err)
+
+		err = g.WithMetadata(Metadata{"auth": "user"}).AddHTTPHandler("/bar", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			v, ok := GetRouteMetadata(req.Context(), "auth")
+			require.True(t, ok)
+			require.Equal(t, "user", v)
+			w.WriteHeader(http.StatusOK)
+		}))
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://localhost:9090/foo", nil)
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodPost, "http://localhost:9090/foo", nil)
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusCreated, rec.Result().StatusCode)
+
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "http://localhost:9090/bar/baz", nil)
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+	})
+}
@@ -0,0 +1,119 @@
+package limi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sanekee/limi/internal/limi"
+)
+
+// handlerRoot is a handler base path or package mount mapped to a routing path prefix.
+type handlerRoot struct {
+	path    string
+	prefix  string
+	isMount bool
+}
+
+// WithHandlerRoot adds a handler base path with the routing path prefix of the handlers discovered under it,
+// i.e. handlers in package `pkg/admin/users` are added at `/admin/users` with WithHandlerRoot("admin", "/admin").
+// The base path is matched with whole package path segments, the deepest matching base path is used
+// when a package path matches multiple base paths, including the router's HandlerPath with an empty prefix.
+//
+// Use WithHandlerRoot to add directories to the handler discovery, as alternatives to HandlerPath (i.e. `handler`, `admin`).
+// Use WithPackageMount to map specific package trees to routing prefixes regardless of the other roots, optionally strictly.
+func WithHandlerRoot(path string, prefix string) RouterOptions {
+	return func(r *Router) error {
+		root, err := newHandlerRoot(path, prefix)
+		if err != nil {
+			return err
+		}
+		r.handlerRoots = append(r.handlerRoots, root)
+		return nil
+	}
+}
+
+// WithPackageMount mounts the handlers in the package path and its sub packages at the routing path prefix,
+// i.e. handlers in package `internal/api/admin/users` are added at `/admin/users` with WithPackageMount("internal/api/admin", "/admin").
+// The package path is matched with whole package path segments, the longest matching package mount is used,
+// package mounts take precedence over the HandlerPath and handler roots, even when a handler root matches deeper.
+func WithPackageMount(pkgPath string, prefix string) RouterOptions {
+	return func(r *Router) error {
+		root, err := newHandlerRoot(pkgPath, prefix)
+		if err != nil {
+			return err
+		}
+		root.isMount = true
+		r.handlerRoots = append(r.handlerRoots, root)
+		return nil
+	}
+}
+
+// WithStrictPackageMounts rejects the handlers added with AddHandler outside of any package mount,
+// when the handler's paths are derived from the package path (i.e. without path tag or with relative paths).
+func WithStrictPackageMounts() RouterOptions {
+	return func(r *Router) error {
+		r.strictMounts = true
+		return nil
+	}
+}
+
+// newHandlerRoot returns a handlerRoot with path and routing path prefix.
+func newHandlerRoot(path string, prefix string) (handlerRoot, error) {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return handlerRoot{}, fmt.Errorf("invalid handler root %w", limi.ErrInvalidInput)
+	}
+	return handlerRoot{
+		path:   path,
+		prefix: removeTraillingSlash(ensureLeadingSlash(prefix)),
+	}, nil
+}
+
+// findHandlerRoot returns the routing path of the package path under the longest matching package mount,
+// or the deepest matching handler root. The package path is returned as is when no handler root matches.
+func findHandlerRoot(roots []handlerRoot, pkgPath string) string {
+	if root, idx, ok := findPackageMount(roots, pkgPath); ok {
+		return root.prefix + pkgPath[idx+len(root.path):]
+	}
+
+	end := -1
+	var root handlerRoot
+	for _, r := range roots {
+		if r.isMount {
+			continue
+		}
+		idx := handlerPathIndex(r.path, pkgPath)
+		if idx < 0 {
+			continue
+		}
+		if i := idx + len(r.path); i > end || (i == end && len(r.path) > len(root.path)) {
+			end = i
+			root = r
+		}
+	}
+
+	if end < 0 {
+		return pkgPath
+	}
+	return root.prefix + pkgPath[end:]
+}
+
+// findPackageMount returns the longest package mount matching the package path, with the index of the match.
+func findPackageMount(roots []handlerRoot, pkgPath string) (handlerRoot, int, bool) {
+	var ret handlerRoot
+	var retIdx int
+	var found bool
+	for _, r := range roots {
+		if !r.isMount {
+			continue
+		}
+		idx := handlerPathIndex(r.path, pkgPath)
+		if idx < 0 {
+			continue
+		}
+		if !found || len(r.path) > len(ret.path) {
+			ret, retIdx, found = r, idx, true
+		}
+	}
+	return ret, retIdx, found
+}
@@ -0,0 +1,134 @@
+package limi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sanekee/limi/internal/testing/handler/foo"
+	"github.com/sanekee/limi/internal/testing/require"
+)
+
+func TestHandlerRoot(t *testing.T) {
+	roots := []handlerRoot{
+		{path: "handler"},
+		{path: "admin", prefix: "/admin"},
+		{path: "internal/api/public", prefix: ""},
+		{path: "api/public", prefix: "/public"},
+	}
+
+	type test struct {
+		pkgPath  string
+		expected string
+	}
+
+	tests := []test{
+		{pkgPath: "base/handler/foo", expected: "/foo"},
+		{pkgPath: "base/handler", expected: ""},
+		{pkgPath: "base/admin/users", expected: "/admin/users"},
+		{pkgPath: "base/admin", expected: "/admin"},
+		{pkgPath: "base/handler/admin/users", expected: "/admin/users"},
+		{pkgPath: "base/admin/handler/users", expected: "/users"},
+		{pkgPath: "base/internal/api/public/items", expected: "/items"},
+		{pkgPath: "base/administrator/users", expected: "base/administrator/users"},
+		{pkgPath: "base/myhandlerx/foo", expected: "base/myhandlerx/foo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pkgPath, func(t *testing.T) {
+			require.Equal(t, tt.expected, findHandlerRoot(roots, tt.pkgPath))
+		})
+	}
+
+	t.Run("option", func(t *testing.T) {
+		r, err := NewRouter("/", WithHandlerRoot("/admin/", "admin/"))
+		require.NoError(t, err)
+		require.Equal(t, []handlerRoot{{path: "handler"}, {path: "admin", prefix: "/admin"}}, r.allHandlerRoots())
+
+		_, err = NewRouter("/", WithHandlerRoot("", "/admin"))
+		require.Error(t, err)
+	})
+}
+
+func TestPackageMount(t *testing.T) {
+	roots := []handlerRoot{
+		{path: "handler"},
+		{path: "internal/api/public", prefix: "", isMount: true},
+		{path: "internal/api/admin", prefix: "/admin", isMount: true},
+		{path: "internal/api/admin/reports", prefix: "/reports", isMount: true},
+		{path: "api/admin", prefix: "/other", isMount: true},
+	}
+
+	type test struct {
+		pkgPath  string
+		expected string
+	}
+
+	tests := []test{
+		{pkgPath: "example.com/app/internal/api/public/items", expected: "/items"},
+		{pkgPath: "example.com/app/internal/api/admin", expected: "/admin"},
+		{pkgPath: "example.com/app/internal/api/admin/users", expected: "/admin/users"},
+		{pkgPath: "example.com/app/internal/api/admin/reports/daily", expected: "/reports/daily"},
+		{pkgPath: "example.com/app/internal/handler/admin/users", expected: "/admin/users"},
+		{pkgPath: "example.com/app/internal/api/administrator", expected: "example.com/app/internal/api/administrator"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pkgPath, func(t *testing.T) {
+			require.Equal(t, tt.expected, findHandlerRoot(roots, tt.pkgPath))
+		})
+	}
+
+	t.Run("mount", func(t *testing.T) {
+		r, err := NewRouter("/", WithPackageMount("internal/testing/handler", "/api"))
+		require.NoError(t, err)
+		require.NoError(t, r.AddHandler(foo.Foo{}))
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/foo", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		body, err := io.ReadAll(rec.Body)
+		require.NoError(t, err)
+		require.Equal(t, "foo", string(body))
+	})
+
+	t.Run("option", func(t *testing.T) {
+		r, err := NewRouter("/", WithPackageMount("internal/api/public/", "/"))
+		require.NoError(t, err)
+		require.Equal(t, []handlerRoot{{path: "handler"}, {path: "internal/api/public", isMount: true}}, r.allHandlerRoots())
+
+		_, err = NewRouter("/", WithPackageMount("/", "/admin"))
+		require.Error(t, err)
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		r, err := NewRouter("/", WithPackageMount("internal/testing/handler", "/api"), WithStrictPackageMounts())
+		require.NoError(t, err)
+		require.NoError(t, r.AddHandler(foo.Foo{}))
+
+		r, err = NewRouter("/", WithPackageMount("internal/api", "/api"), WithStrictPackageMounts())
+		require.NoError(t, err)
+		require.Error(t, r.AddHandler(foo.Foo{}))
+
+		// resources with custom path are not resolved from the package
+		require.NoError(t, r.AddResource("/teams", testTeams{}))
+
+		// absolute paths are not resolved from the package, relative paths are
+		require.NoError(t, r.AddHandler(testStatusHandler{}))
+		require.Error(t, r.AddHandler(testRelativeStatusHandler{}))
+	})
+}
+
+type testStatusHandler struct {
+	_ struct{} `limi:"path=/status"`
+}
+
+func (testStatusHandler) Get(w http.ResponseWriter, req *http.Request) {}
+
+type testRelativeStatusHandler struct {
+	_ struct{} `limi:"path=status"`
+}
+
+func (testRelativeStatusHandler) Get(w http.ResponseWriter, req *http.Request) {}
@@ -0,0 +1,233 @@
+package limi
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sanekee/limi/internal/limi"
+)
+
+// splitHostPort splits the request host (i.e. `example.com:8080`, `[::1]:8080`) into host and port.
+// IPv6 literal host is returned without the brackets.
+func splitHostPort(hostport string) (string, string) {
+	host, port := hostport, ""
+
+	// an empty port (i.e. `example.com:`) is split as no port, a bare IPv6 address has more than one ':'
+	colon := strings.LastIndexByte(host, ':')
+	if colon >= 0 && (isPort(host[colon+1:]) || colon == len(host)-1) &&
+		(strings.HasPrefix(host, "[") || strings.Count(host, ":") == 1) {
+		host, port = host[:colon], host[colon+1:]
+	}
+
+	if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		host = host[1 : len(host)-1]
+	}
+
+	return host, port
+}
+
+// joinHostPort returns the host with port, IPv6 literal host is enclosed in brackets.
+func joinHostPort(host, port string) string {
+	if strings.IndexByte(host, ':') >= 0 {
+		host = "[" + host + "]"
+	}
+	if port == "" {
+		return host
+	}
+	return host + ":" + port
+}
+
+// isPort returns true if str is a non empty decimal port.
+func isPort(str string) bool {
+	if str == "" {
+		return false
+	}
+	for i := 0; i < len(str); i++ {
+		if str[i] < '0' || str[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// hostKey returns the key of host in a host tree.
+// Hosts are prefixed with a dot so that patterns starting with a string or a label share the same root node.
+func hostKey(host string) string {
+	return "." + host
+}
+
+// normalizeHost returns the lower case host without the trailing dot, internationalized labels are converted to punycode.
+func normalizeHost(host string) string {
+	host = strings.TrimSuffix(host, ".")
+	if strings.IndexByte(host, ':') >= 0 {
+		// IPv6 literal
+		return strings.ToLower(host)
+	}
+	return toASCII(host)
+}
+
+// normalizeHostPattern returns the host pattern with string parts normalized, and true if the pattern matches a port.
+func normalizeHostPattern(pattern string) (string, bool, error) {
+	parsers, err := limi.SplitParsers(pattern)
+	if err != nil {
+		return "", false, err
+	}
+
+	var sb strings.Builder
+	var stringParts strings.Builder
+	for i, p := range parsers {
+		if p.Type != limi.TypeString {
+			sb.WriteString(p.Str)
+			continue
+		}
+
+		str := p.Str
+		if i == len(parsers)-1 {
+			str = strings.TrimSuffix(str, ".")
+		}
+		stringParts.WriteString(str)
+		sb.WriteString(toASCII(str))
+	}
+
+	normalized := sb.String()
+
+	// port is matched when a ':' is found outside of labels and IPv6 literal
+	strs := stringParts.String()
+	var hasPort bool
+	if idx := strings.LastIndexByte(strs, ']'); idx >= 0 {
+		hasPort = strings.IndexByte(strs[idx+1:], ':') >= 0
+	} else {
+		hasPort = strings.Count(strs, ":") == 1
+	}
+
+	if !hasPort &&
+		strings.HasPrefix(normalized, "[") && strings.HasSuffix(normalized, "]") {
+		normalized = normalized[1 : len(normalized)-1]
+	}
+
+	return normalized, hasPort, nil
+}
+
+// toASCII returns the lower case host with non ASCII labels converted to punycode (i.e. `xn--mnchen-3ya`).
+// Labels are only lower cased, the UTS #46 mapping (i.e. full width characters, `ß`) and validation are not applied,
+// hosts must be configured in their lower case Unicode form as sent by the clients.
+func toASCII(host string) string {
+	host = strings.ToLower(host)
+	labels := strings.Split(host, ".")
+	for i, l := range labels {
+		if isASCII(l) {
+			continue
+		}
+		labels[i] = "xn--" + punycode(l)
+	}
+	return strings.Join(labels, ".")
+}
+
+// isASCII returns true if str contains only ASCII characters.
+func isASCII(str string) bool {
+	for i := 0; i < len(str); i++ {
+		if str[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// punycode parameters defined in RFC 3492.
+const (
+	punyBase        = 36
+	punyTMin        = 1
+	punyTMax        = 26
+	punySkew        = 38
+	punyDamp        = 700
+	punyInitialBias = 72
+	punyInitialN    = 128
+)
+
+// punycode encodes str with the RFC 3492 punycode algorithm.
+func punycode(str string) string {
+	runes := []rune(str)
+
+	var out []byte
+	for _, r := range runes {
+		if r < utf8.RuneSelf {
+			out = append(out, byte(r))
+		}
+	}
+
+	basic := len(out)
+	handled := basic
+	if basic > 0 {
+		out = append(out, '-')
+	}
+
+	n, delta, bias := punyInitialN, 0, punyInitialBias
+	for handled < len(runes) {
+		m := int(utf8.MaxRune) + 1
+		for _, r := range runes {
+			if int(r) >= n && int(r) < m {
+				m = int(r)
+			}
+		}
+
+		delta += (m - n) * (handled + 1)
+		n = m
+
+		for _, r := range runes {
+			if int(r) < n {
+				delta++
+			}
+			if int(r) != n {
+				continue
+			}
+
+			q := delta
+			for k := punyBase; ; k += punyBase {
+				t := k - bias
+				if t < punyTMin {
+					t = punyTMin
+				} else if t > punyTMax {
+					t = punyTMax
+				}
+				if q < t {
+					break
+				}
+				out = append(out, punyDigit(t+(q-t)%(punyBase-t)))
+				q = (q - t) / (punyBase - t)
+			}
+			out = append(out, punyDigit(q))
+			bias = punyAdapt(delta, handled+1, handled == basic)
+			delta = 0
+			handled++
+		}
+		delta++
+		n++
+	}
+
+	return string(out)
+}
+
+// punyAdapt returns the adapted punycode bias.
+func punyAdapt(delta, numPoints int, first bool) int {
+	if first {
+		delta /= punyDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / numPoints
+
+	k := 0
+	for delta > ((punyBase-punyTMin)*punyTMax)/2 {
+		delta /= punyBase - punyTMin
+		k += punyBase
+	}
+	return k + (punyBase-punyTMin+1)*delta/(delta+punySkew)
+}
+
+// punyDigit returns the punycode basic code point of digit d.
+func punyDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
@@ -0,0 +1,341 @@
+package limi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/sanekee/limi/internal/testing/handler"
+	"github.com/sanekee/limi/internal/testing/require"
+)
+
+func TestSplitHostPort(t *testing.T) {
+	type test struct {
+		input string
+		host  string
+		port  string
+	}
+
+	tests := []test{
+		{input: "host:8080", host: "host", port: "8080"},
+		{input: "host", host: "host"},
+		{input: "", host: ""},
+		{input: "host:", host: "host"},
+		{input: "[::1]:", host: "::1"},
+		{input: "[::1]:8080", host: "::1", port: "8080"},
+		{input: "[::1]", host: "::1"},
+		{input: "::1", host: "::1"},
+		{input: "[fe80::1%25en0]:443", host: "fe80::1%25en0", port: "443"},
+		{input: "127.0.0.1:80", host: "127.0.0.1", port: "80"},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			host, port := splitHostPort(tt.input)
+			require.Equal(t, tt.host, host)
+			require.Equal(t, tt.port, port)
+		})
+	}
+}
+
+func TestNormalizeHost(t *testing.T) {
+	type test struct {
+		input    string
+		expected string
+	}
+
+	tests := []test{
+		{input: "Example.COM", expected: "example.com"},
+		{input: "example.com.", expected: "example.com"},
+		{input: "München.de", expected: "xn--mnchen-3ya.de"},
+		{input: "bücher.example", expected: "xn--bcher-kva.example"},
+		{input: "FE80::1", expected: "fe80::1"},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			require.Equal(t, tt.expected, normalizeHost(tt.input))
+		})
+	}
+}
+
+func TestNormalizeHostPattern(t *testing.T) {
+	type test struct {
+		input    string
+		expected string
+		hasPort  bool
+	}
+
+	tests := []test{
+		{input: "Example.com", expected: "example.com"},
+		{input: "{sub}.Example.com", expected: "{sub}.example.com"},
+		{input: "{Host:[^.]+.hostname.com}", expected: "{Host:[^.]+.hostname.com}"},
+		{input: "example.com:8080", expected: "example.com:8080", hasPort: true},
+		{input: "{sub}.example.com:{port}", expected: "{sub}.example.com:{port}", hasPort: true},
+		{input: "[::1]", expected: "::1"},
+		{input: "[::1]:8080", expected: "[::1]:8080", hasPort: true},
+		{input: "münchen.de", expected: "xn--mnchen-3ya.de"},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			actual, hasPort, err := normalizeHostPattern(tt.input)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+			require.Equal(t, tt.hasPort, hasPort)
+		})
+	}
+}
+
+func TestPunycode(t *testing.T) {
+	type test struct {
+		name     string
+		input    string
+		expected string
+	}
+
+	// RFC 3492 7.1 sample strings
+	tests := []test{
+		{name: "A Arabic", input: "ليهمابتكلموشعربي؟", expected: "egbpdaj6bu4bxfgehfvwxn"},
+		{name: "B Chinese simplified", input: "他们为什么不说中文", expected: "ihqwcrb4cv8a8dqg056pqjye"},
+		{name: "C Chinese traditional", input: "他們爲什麽不說中文", expected: "ihqwctvzc91f659drss3x8bo0yb"},
+		{name: "D Czech", input: "Pročprostěnemluvíčesky", expected: "Proprostnemluvesky-uyb24dma41a"},
+		{name: "E Hebrew", input: "למההםפשוטלאמדבריםעברית", expected: "4dbcagdahymbxekheh6e0a7fei0b"},
+		{name: "F Hindi", input: "यहलोगहिन्दीक्योंनहींबोलसकतेहैं", expected: "i1baa7eci9glrd9b2ae1bj0hfcgg6iyaf8o0a1dig0cd"},
+		{name: "G Japanese", input: "なぜみんな日本語を話してくれないのか", expected: "n8jok5ay5dzabd5bym9f0cm5685rrjetr6pdxa"},
+		{name: "H Korean", input: "세계의모든사람들이한국어를이해한다면얼마나좋을까", expected: "989aomsvi5e83db1d2a355cv1e0vak1dwrv93d5xbh15a0dt30a5jpsd879ccm6fea98c"},
+		{name: "I Russian", input: "почемужеонинеговорятпорусски", expected: "b1abfaaepdrnnbgefbadotcwatmq2g4l"},
+		{name: "J Spanish", input: "PorquénopuedensimplementehablarenEspañol", expected: "PorqunopuedensimplementehablarenEspaol-fmd56a"},
+		{name: "K Vietnamese", input: "TạisaohọkhôngthểchỉnóitiếngViệt", expected: "TisaohkhngthchnitingVit-kjcr8268qyxafd2f1b9g"},
+		{name: "L", input: "3年B組金八先生", expected: "3B-ww4c5e180e575a65lsy2b"},
+		{name: "M", input: "安室奈美恵-with-SUPER-MONKEYS", expected: "-with-SUPER-MONKEYS-pc58ag80a8qai00g7n9n"},
+		{name: "N", input: "Hello-Another-Way-それぞれの場所", expected: "Hello-Another-Way--fc4qua05auwb3674vfr0b"},
+		{name: "O", input: "ひとつ屋根の下2", expected: "2-u9tlzr9756bt3uc0v"},
+		{name: "P", input: "MajiでKoiする5秒前", expected: "MajiKoi5-783gue6qz075azm5e"},
+		{name: "Q", input: "パフィーdeルンバ", expected: "de-jg4avhby1noc0d"},
+		{name: "R", input: "そのスピードで", expected: "d9juau41awczczp"},
+		{name: "S", input: "-> $1.00 <-", expected: "-> $1.00 <--"},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, punycode(tt.input))
+		})
+	}
+}
+
+func TestHosts(t *testing.T) {
+	newRouter := func(t *testing.T, hosts ...string) *Router {
+		r, err := NewRouter("/", WithHosts(hosts...))
+		require.NoError(t, err)
+
+		err = r.AddHandlerFunc("/foo", http.MethodGet, func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(GetURLParam(req.Context(), "port"))) // nolint:errcheck
+		})
+		require.NoError(t, err)
+		return r
+	}
+
+	type test struct {
+		testName string
+		hosts    []string
+		url      string
+		status   int
+		body     string
+	}
+
+	tests := []test{
+		{testName: "IPv6 with port", hosts: []string{"[::1]"}, url: "http://[::1]:8080/foo", status: http.StatusOK},
+		{testName: "IPv6", hosts: []string{"::1"}, url: "http://[::1]/foo", status: http.StatusOK},
+		{testName: "upper case", hosts: []string{"example.com"}, url: "http://EXAMPLE.com/foo", status: http.StatusOK},
+		{testName: "trailing dot", hosts: []string{"example.com"}, url: "http://example.com./foo", status: http.StatusOK},
+		{testName: "idna", hosts: []string{"münchen.de"}, url: "http://xn--mnchen-3ya.de/foo", status: http.StatusOK},
+		{testName: "with port", hosts: []string{"example.com:8080"}, url: "http://example.com:8080/foo", status: http.StatusOK},
+		{testName: "with different port", hosts: []string{"example.com:8080"}, url: "http://example.com:9090/foo", status: http.StatusNotFound},
+		{testName: "with port, no port", hosts: []string{"example.com:8080"}, url: "http://example.com/foo", status: http.StatusNotFound},
+		{testName: "string and label", hosts: []string{"www.example.com", "{sub}.example.com"}, url: "http://api.example.com/foo", status: http.StatusOK},
+		{testName: "with port label", hosts: []string{"example.com:{port}"}, url: "http://example.com:9090/foo", status: http.StatusOK, body: "9090"},
+		{testName: "IPv6 with port label", hosts: []string{"[::1]:{port}"}, url: "http://[::1]:9090/foo", status: http.StatusOK, body: "9090"},
+		{testName: "mixed", hosts: []string{"admin:8081", "public"}, url: "http://public:8080/foo", status: http.StatusOK},
+		{testName: "mixed, port", hosts: []string{"admin:8081", "public"}, url: "http://admin:8081/foo", status: http.StatusOK},
+		{testName: "mixed, wrong port", hosts: []string{"admin:8081", "public"}, url: "http://admin:8080/foo", status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			r := newRouter(t, tt.hosts...)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+
+			r.ServeHTTP(rec, req)
+			require.Equal(t, tt.status, rec.Result().StatusCode)
+
+			if tt.body != "" {
+				body, err := io.ReadAll(rec.Body)
+				require.NoError(t, err)
+				require.Equal(t, tt.body, string(body))
+			}
+		})
+	}
+
+	t.Run("mux with different ports", func(t *testing.T) {
+		m := NewMux()
+		r1, err := m.AddRouter("/", WithHosts("localhost:8081"))
+		require.NoError(t, err)
+
+		err = r1.AddHandlerFunc("/", http.MethodGet, handler.NewHandlerFunc(http.StatusOK, nil, []byte("admin")))
+		require.NoError(t, err)
+
+		r2, err := m.AddRouter("/", WithHosts("localhost:8080"))
+		require.NoError(t, err)
+
+		err = r2.AddHandlerFunc("/", http.MethodGet, handler.NewHandlerFunc(http.StatusOK, nil, []byte("public")))
+		require.NoError(t, err)
+
+		for url, expected := range map[string]string{
+			"http://localhost:8081/": "admin",
+			"http://localhost:8080/": "public",
+		} {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, url, nil)
+
+			m.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+			body, err := io.ReadAll(rec.Body)
+			require.NoError(t, err)
+			require.Equal(t, expected, string(body))
+		}
+	})
+}
+
+type tenantParams struct {
+	tenant string `limi:"host=subdomain"`
+	id     int    `limi:"param"`
+}
+
+type tenantHandler struct {
+	_ tenantParams `limi:"path=/items/{id}"`
+}
+
+func (t tenantHandler) Get(w http.ResponseWriter, req *http.Request) {
+	params, err := GetParams[tenantParams](req.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(params.tenant + ":" + strconv.Itoa(params.id))) // nolint:errcheck
+}
+
+func TestHostParams(t *testing.T) {
+	t.Run("params binding", func(t *testing.T) {
+		r, err := NewRouter("/", WithHosts("{subdomain}.example.com"))
+		require.NoError(t, err)
+
+		err = r.AddHandler(tenantHandler{})
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://tenant1.example.com/items/3", nil)
+
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		body, err := io.ReadAll(rec.Body)
+		require.NoError(t, err)
+		require.Equal(t, "tenant1:3", string(body))
+	})
+
+	t.Run("mux", func(t *testing.T) {
+		m := NewMux()
+		r1, err := m.AddRouter("/", WithHosts("{region}.example.com"))
+		require.NoError(t, err)
+
+		err = r1.AddHandlerFunc("/foo", http.MethodGet, handler.NewHandlerFunc(http.StatusOK, nil, []byte("foo")))
+		require.NoError(t, err)
+
+		r2, err := m.AddRouter("/", WithHosts("{subdomain}.example.com"))
+		require.NoError(t, err)
+
+		err = r2.AddHandlerFunc("/bar", http.MethodGet, func(w http.ResponseWriter, req *http.Request) {
+			require.Empty(t, HostParam(req.Context(), "region"))
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(HostParam(req.Context(), "subdomain"))) // nolint:errcheck
+		})
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://tenant1.example.com/bar", nil)
+
+		m.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		body, err := io.ReadAll(rec.Body)
+		require.NoError(t, err)
+		require.Equal(t, "tenant1", string(body))
+	})
+
+	t.Run("subrouter", func(t *testing.T) {
+		r, err := NewRouter("/", WithHosts("{tenant}.example.com"))
+		require.NoError(t, err)
+
+		_, err = r.AddRouter("/api", WithHosts("{region}.eu.example.com"))
+		require.NoError(t, err)
+
+		r2, err := r.AddRouter("/api", WithHosts("{name}.example.com"))
+		require.NoError(t, err)
+
+		err = r2.AddHandlerFunc("/foo", http.MethodGet, func(w http.ResponseWriter, req *http.Request) {
+			require.Empty(t, HostParam(req.Context(), "region"))
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(HostParam(req.Context(), "tenant") + ":" + HostParam(req.Context(), "name"))) // nolint:errcheck
+		})
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://tenant1.example.com/api/foo", nil)
+
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		body, err := io.ReadAll(rec.Body)
+		require.NoError(t, err)
+		require.Equal(t, ":tenant1", string(body))
+	})
+
+	t.Run("subrouter fallback", func(t *testing.T) {
+		r, err := NewRouter("/")
+		require.NoError(t, err)
+
+		r1, err := r.AddRouter("/api", WithHosts("{tenant}.example.com"))
+		require.NoError(t, err)
+		err = r1.AddHandlerFunc("/bar", http.MethodGet, handler.NewHandlerFunc(http.StatusOK, nil, []byte("bar")))
+		require.NoError(t, err)
+
+		r2, err := r.AddRouter("/api", WithHosts("{other}.example.com"))
+		require.NoError(t, err)
+		err = r2.AddHandlerFunc("/foo", http.MethodGet, func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("tenant=" + HostParam(req.Context(), "tenant") + " other=" + GetURLParam(req.Context(), "other"))) // nolint:errcheck
+		})
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://acme.example.com/api/foo", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "tenant= other=acme", rec.Body.String())
+	})
+}
@@ -0,0 +1,169 @@
+package limi
+
+import (
+	"net/http"
+
+	"github.com/sanekee/limi/internal/limi"
+)
+
+// hostIndex indexes routers by their host patterns.
+// The candidate routers of every host pattern are ordered when the routers are added,
+// a request host is resolved with a single lookup on the host trees and the candidates are tried in the order the routers were added.
+type hostIndex struct {
+	host     *limi.Node
+	hostPort *limi.Node
+	// others are the routers tried for every host, routers matching any host and routers with hosts not indexed.
+	others   []hostEntry
+	patterns []*hostRouters
+}
+
+// hostEntry is a candidate router of a host.
+type hostEntry struct {
+	index int
+	// indexed is true when the host is matched by the index, otherwise the router matches the host itself.
+	indexed bool
+}
+
+// add indexes router r at position i, routers must be added in the order of their positions.
+func (x *hostIndex) add(i int, r *Router) {
+	defer x.order()
+
+	if len(r.hostPatterns) == 0 && len(r.hostPortPatterns) == 0 {
+		x.others = append(x.others, hostEntry{index: i})
+		return
+	}
+
+	if !x.insert(&x.host, i, r.hostPatterns) || !x.insert(&x.hostPort, i, r.hostPortPatterns) {
+		// patterns are validated by the router, fallback to matching by the router itself
+		x.others = append(x.others, hostEntry{index: i})
+	}
+}
+
+// insert inserts the host patterns of router i to the host tree n, returns false if a pattern is not inserted.
+func (x *hostIndex) insert(n **limi.Node, i int, patterns []string) bool {
+	for _, p := range patterns {
+		if *n == nil {
+			*n = &limi.Node{}
+		}
+		hr := &hostRouters{indexes: []int{i}}
+		if err := (*n).Insert(hostKey(p), hr); err != nil {
+			return false
+		}
+		x.patterns = append(x.patterns, hr)
+	}
+	return true
+}
+
+// order merges the routers of every host pattern with the routers tried for every host.
+func (x *hostIndex) order() {
+	for _, hr := range x.patterns {
+		entries := make([]hostEntry, 0, len(hr.indexes)+len(x.others))
+		others := x.others
+		for _, i := range hr.indexes {
+			for len(others) > 0 && others[0].index < i {
+				entries = append(entries, others[0])
+				others = others[1:]
+			}
+			if len(others) > 0 && others[0].index == i {
+				others = others[1:]
+			}
+			entries = append(entries, hostEntry{index: i, indexed: true})
+		}
+		hr.entries = append(entries, others...)
+	}
+}
+
+// lookup resolves the routers which may support host to it.
+func (x *hostIndex) lookup(host string, it *hostIter) {
+	hostname, port := splitHostPort(host)
+	hostname = normalizeHost(hostname)
+
+	it.matches = it.buf[:0]
+	collect := func(h limi.Handle, params map[string]string) {
+		if hr, ok := h.(*hostRouters); ok {
+			it.matches = append(it.matches, hostMatch{entries: hr.entries, params: params})
+		}
+	}
+
+	if x.host != nil {
+		x.host.LookupAll(hostKey(hostname), collect)
+	}
+	if x.hostPort != nil && port != "" {
+		x.hostPort.LookupAll(hostKey(joinHostPort(hostname, port)), collect)
+	}
+
+	if len(it.matches) == 0 {
+		it.matches = append(it.matches, hostMatch{entries: x.others})
+	}
+}
+
+// hostMatch is a host pattern matched by a request host.
+type hostMatch struct {
+	entries []hostEntry
+	params  map[string]string
+}
+
+// hostIter iterates the candidate routers of the host patterns matched by a request host, in the order the routers were added.
+type hostIter struct {
+	matches []hostMatch
+	buf     [2]hostMatch
+}
+
+// next returns the next candidate router with the host params of the matched pattern, false when there is no more router.
+func (it *hostIter) next() (hostEntry, map[string]string, bool) {
+	next := -1
+	for _, m := range it.matches {
+		if len(m.entries) > 0 && (next < 0 || m.entries[0].index < next) {
+			next = m.entries[0].index
+		}
+	}
+	if next < 0 {
+		return hostEntry{}, nil, false
+	}
+
+	// a router matched by several patterns is returned once, with the params of the first pattern
+	e := hostEntry{index: next}
+	var params map[string]string
+	for j := range it.matches {
+		m := &it.matches[j]
+		if len(m.entries) == 0 || m.entries[0].index != next {
+			continue
+		}
+		if m.entries[0].indexed && !e.indexed {
+			e.indexed = true
+			params = m.params
+		}
+		m.entries = m.entries[1:]
+	}
+	return e, params, true
+}
+
+// hostRouters is a node Handle holding indexes of routers sharing a host pattern.
+type hostRouters struct {
+	indexes []int
+	// entries are the candidate routers of the host pattern, ordered when the routers are added.
+	entries []hostEntry
+}
+
+// IsPartial implements Node Handle interface, host patterns are fully matched.
+func (h *hostRouters) IsPartial() bool {
+	return false
+}
+
+// Merge implements Node Handle interface, merges routers with the same host pattern.
+func (h *hostRouters) Merge(hh limi.Handle) bool {
+	o, ok := hh.(*hostRouters)
+	if !ok {
+		return false
+	}
+	h.indexes = append(h.indexes, o.indexes...)
+	return true
+}
+
+// IsMethodAllowed implements Node Handle interface.
+func (h *hostRouters) IsMethodAllowed(string) bool {
+	return true
+}
+
+// ServeHTTP implements Node Handle interface.
+func (h *hostRouters) ServeHTTP(http.ResponseWriter, *http.Request) {}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"reflect"
 	"strconv"
@@ -13,14 +14,26 @@ import (
 
 type ctxKey string
 
-var limiContextKey ctxKey = "limi context"
+var (
+	limiContextKey ctxKey = "limi context"
+	requestIDKey   ctxKey = "request id"
+)
 
 type limiContext struct {
-	urlParams map[string]string
-	queries   map[string]string
+	urlParams  map[string]string
+	queries    map[string]string
+	hostParams map[string]string
 
 	routingPath string
 	paramsType  reflect.Type
+	metadata    map[string]any
+	apiVersion  string
+	renderer    any
+	resourceID  string
+	errHandler  http.Handler
+	notFound    http.Handler
+	pattern     string
+	methods     []string
 }
 
 func NewContext(ctx context.Context) context.Context {
@@ -30,8 +43,9 @@ func NewContext(ctx context.Context) context.Context {
 	}
 
 	lCtx := &limiContext{
-		urlParams: make(map[string]string),
-		queries:   make(map[string]string),
+		urlParams:  make(map[string]string),
+		queries:    make(map[string]string),
+		hostParams: make(map[string]string),
 	}
 	return context.WithValue(ctx, limiContextKey, lCtx)
 }
@@ -55,8 +69,20 @@ func ResetContext(ctx context.Context) {
 		lCtx.queries = make(map[string]string)
 	}
 
+	if len(lCtx.hostParams) > 0 {
+		lCtx.hostParams = make(map[string]string)
+	}
+
 	lCtx.routingPath = ""
 	lCtx.paramsType = nil
+	lCtx.metadata = nil
+	lCtx.apiVersion = ""
+	lCtx.renderer = nil
+	lCtx.resourceID = ""
+	lCtx.errHandler = nil
+	lCtx.notFound = nil
+	lCtx.pattern = ""
+	lCtx.methods = nil
 }
 
 func GetURLParam(ctx context.Context, key string) string {
@@ -77,6 +103,80 @@ func SetURLParam(ctx context.Context, key, val string) {
 	lCtx.urlParams[key] = val
 }
 
+func GetURLParams(ctx context.Context) map[string]string {
+	lCtx, ok := ctx.Value(limiContextKey).(*limiContext)
+	if !ok {
+		return nil
+	}
+
+	return lCtx.urlParams
+}
+
+func GetHostParam(ctx context.Context, key string) string {
+	lCtx, ok := ctx.Value(limiContextKey).(*limiContext)
+	if !ok {
+		return ""
+	}
+
+	return lCtx.hostParams[key]
+}
+
+// SetHostParams replaces the host params, host params are also set as url params.
+func SetHostParams(ctx context.Context, params map[string]string) {
+	lCtx, ok := ctx.Value(limiContextKey).(*limiContext)
+	if !ok {
+		return
+	}
+	if len(lCtx.hostParams) == 0 && len(params) == 0 {
+		return
+	}
+
+	for k := range lCtx.hostParams {
+		delete(lCtx.urlParams, k)
+	}
+	lCtx.hostParams = make(map[string]string, len(params))
+	for k, v := range params {
+		lCtx.hostParams[k] = v
+		lCtx.urlParams[k] = v
+	}
+}
+
+// Params is a snapshot of the url and host params.
+type Params struct {
+	urlParams  map[string]string
+	hostParams map[string]string
+}
+
+// SaveParams returns a snapshot of the url and host params, restored with RestoreParams.
+func SaveParams(ctx context.Context) Params {
+	lCtx, ok := ctx.Value(limiContextKey).(*limiContext)
+	if !ok {
+		return Params{}
+	}
+
+	return Params{urlParams: copyParams(lCtx.urlParams), hostParams: copyParams(lCtx.hostParams)}
+}
+
+// RestoreParams restores the url and host params of the snapshot.
+func RestoreParams(ctx context.Context, p Params) {
+	lCtx, ok := ctx.Value(limiContextKey).(*limiContext)
+	if !ok || p.urlParams == nil {
+		return
+	}
+
+	lCtx.urlParams = p.urlParams
+	lCtx.hostParams = p.hostParams
+}
+
+// copyParams returns a copy of the params.
+func copyParams(params map[string]string) map[string]string {
+	cp := make(map[string]string, len(params))
+	for k, v := range params {
+		cp[k] = v
+	}
+	return cp
+}
+
 func GetRoutingPath(ctx context.Context) string {
 	lCtx, ok := ctx.Value(limiContextKey).(*limiContext)
 	if !ok {
@@ -106,6 +206,163 @@ func SetQueries(ctx context.Context, queries url.Values) {
 	}
 }
 
+func SetRouteMetadata(ctx context.Context, md map[string]any) {
+	lCtx, ok := ctx.Value(limiContextKey).(*limiContext)
+	if !ok {
+		return
+	}
+
+	lCtx.metadata = md
+}
+
+func GetRouteMetadata(ctx context.Context, key string) (any, bool) {
+	lCtx, ok := ctx.Value(limiContextKey).(*limiContext)
+	if !ok {
+		return nil, false
+	}
+
+	v, ok := lCtx.metadata[key]
+	return v, ok
+}
+
+func SetAPIVersion(ctx context.Context, version string) {
+	lCtx, ok := ctx.Value(limiContextKey).(*limiContext)
+	if !ok {
+		return
+	}
+
+	lCtx.apiVersion = version
+}
+
+func GetAPIVersion(ctx context.Context) string {
+	lCtx, ok := ctx.Value(limiContextKey).(*limiContext)
+	if !ok {
+		return ""
+	}
+
+	return lCtx.apiVersion
+}
+
+func SetResourceIDParam(ctx context.Context, key string) {
+	lCtx, ok := ctx.Value(limiContextKey).(*limiContext)
+	if !ok {
+		return
+	}
+
+	lCtx.resourceID = key
+}
+
+func GetResourceIDParam(ctx context.Context) string {
+	lCtx, ok := ctx.Value(limiContextKey).(*limiContext)
+	if !ok {
+		return ""
+	}
+
+	return lCtx.resourceID
+}
+
+func SetRoutePattern(ctx context.Context, pattern string) {
+	lCtx, ok := ctx.Value(limiContextKey).(*limiContext)
+	if !ok {
+		return
+	}
+
+	lCtx.pattern = pattern
+}
+
+func GetRoutePattern(ctx context.Context) string {
+	lCtx, ok := ctx.Value(limiContextKey).(*limiContext)
+	if !ok {
+		return ""
+	}
+
+	return lCtx.pattern
+}
+
+func SetAllowedMethods(ctx context.Context, methods []string) {
+	lCtx, ok := ctx.Value(limiContextKey).(*limiContext)
+	if !ok {
+		return
+	}
+
+	lCtx.methods = methods
+}
+
+func GetAllowedMethods(ctx context.Context) []string {
+	lCtx, ok := ctx.Value(limiContextKey).(*limiContext)
+	if !ok {
+		return nil
+	}
+
+	return lCtx.methods
+}
+
+func SetInternalErrorHandler(ctx context.Context, h http.Handler) {
+	lCtx, ok := ctx.Value(limiContextKey).(*limiContext)
+	if !ok {
+		return
+	}
+
+	lCtx.errHandler = h
+}
+
+func GetInternalErrorHandler(ctx context.Context) http.Handler {
+	lCtx, ok := ctx.Value(limiContextKey).(*limiContext)
+	if !ok {
+		return nil
+	}
+
+	return lCtx.errHandler
+}
+
+// SetNotFoundHandler sets the not found handler of the router dispatching the request.
+func SetNotFoundHandler(ctx context.Context, h http.Handler) {
+	lCtx, ok := ctx.Value(limiContextKey).(*limiContext)
+	if !ok {
+		return
+	}
+
+	lCtx.notFound = h
+}
+
+// GetNotFoundHandler returns the not found handler of the router dispatching the request.
+func GetNotFoundHandler(ctx context.Context) http.Handler {
+	lCtx, ok := ctx.Value(limiContextKey).(*limiContext)
+	if !ok {
+		return nil
+	}
+
+	return lCtx.notFound
+}
+
+// WithRequestID returns the context with the request ID, the request ID is set before the limi context is created.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+func GetRequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+func SetRenderer(ctx context.Context, renderer any) {
+	lCtx, ok := ctx.Value(limiContextKey).(*limiContext)
+	if !ok {
+		return
+	}
+
+	lCtx.renderer = renderer
+}
+
+func GetRenderer(ctx context.Context) any {
+	lCtx, ok := ctx.Value(limiContextKey).(*limiContext)
+	if !ok {
+		return nil
+	}
+
+	return lCtx.renderer
+}
+
 type stringer interface {
 	FromString(str string) error
 }
@@ -124,6 +381,20 @@ func ParseURLParam(ctx context.Context, key string, data any) error {
 	return parseValue(data, value)
 }
 
+func ParseHostParam(ctx context.Context, key string, data any) error {
+	lCtx, ok := ctx.Value(limiContextKey).(*limiContext)
+	if !ok {
+		return errors.New("invalid context")
+	}
+
+	value, ok := lCtx.hostParams[key]
+	if !ok {
+		return fmt.Errorf("value not found for host key %s", key)
+	}
+
+	return parseValue(data, value)
+}
+
 func ParseQuery(ctx context.Context, key string, data any) error {
 	lCtx, ok := ctx.Value(limiContextKey).(*limiContext)
 	if !ok {
@@ -187,6 +458,14 @@ func ParseURLParams(ctx context.Context, data any) error {
 				return fmt.Errorf("failed to parse query %s %w", param, err)
 			}
 		}
+
+		host := getHost(tField)
+		if host != "" {
+			ptr := reflect.NewAt(vField.Type(), unsafe.Pointer(vField.UnsafeAddr()))
+			if err := ParseHostParam(ctx, host, ptr.Interface()); err != nil {
+				return fmt.Errorf("failed to parse host param %s %w", host, err)
+			}
+		}
 	}
 	return nil
 
@@ -271,6 +550,25 @@ func getQuery(field reflect.StructField) string {
 	return strs[1]
 }
 
+func getHost(field reflect.StructField) string {
+	limiTag := field.Tag.Get("limi")
+	if limiTag == "" {
+		return ""
+	}
+
+	strs := strings.Split(limiTag, "=")
+	if len(strs) < 1 ||
+		strs[0] != "host" {
+		return ""
+	}
+
+	if len(strs) == 1 {
+		return field.Name
+	}
+
+	return strs[1]
+}
+
 func SetParamsType(ctx context.Context, t reflect.Type) error {
 	lCtx, ok := ctx.Value(limiContextKey).(*limiContext)
 	if !ok {
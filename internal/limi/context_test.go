@@ -54,6 +54,83 @@ func TestContext(t *testing.T) {
 	})
 }
 
+func TestHostParams(t *testing.T) {
+	t.Run("set", func(t *testing.T) {
+		ctx := NewContext(context.Background())
+
+		SetHostParams(ctx, map[string]string{"subdomain": "tenant1"})
+		require.Equal(t, "tenant1", GetHostParam(ctx, "subdomain"))
+		require.Equal(t, "tenant1", GetURLParam(ctx, "subdomain"))
+
+		var actual string
+		err := ParseHostParam(ctx, "subdomain", &actual)
+		require.NoError(t, err)
+		require.Equal(t, "tenant1", actual)
+
+		err = ParseHostParam(ctx, "missing", &actual)
+		require.Error(t, err)
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		ctx := NewContext(context.Background())
+
+		SetHostParams(ctx, map[string]string{"subdomain": "tenant1"})
+		ResetContext(ctx)
+		require.Empty(t, GetHostParam(ctx, "subdomain"))
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		ctx := NewContext(context.Background())
+		SetURLParam(ctx, "id", "1")
+
+		SetHostParams(ctx, map[string]string{"subdomain": "tenant1"})
+		SetHostParams(ctx, map[string]string{"region": "eu"})
+		require.Empty(t, GetHostParam(ctx, "subdomain"))
+		require.Empty(t, GetURLParam(ctx, "subdomain"))
+		require.Equal(t, "eu", GetURLParam(ctx, "region"))
+		require.Equal(t, "1", GetURLParam(ctx, "id"))
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		ctx := NewContext(context.Background())
+		SetHostParams(ctx, map[string]string{"subdomain": "tenant1"})
+
+		saved := SaveParams(ctx)
+		SetHostParams(ctx, map[string]string{"region": "eu"})
+		SetURLParam(ctx, "id", "1")
+		RestoreParams(ctx, saved)
+
+		require.Equal(t, "tenant1", GetHostParam(ctx, "subdomain"))
+		require.Empty(t, GetHostParam(ctx, "region"))
+		require.Empty(t, GetURLParam(ctx, "region"))
+		require.Empty(t, GetURLParam(ctx, "id"))
+	})
+}
+
+func TestRouteMetadata(t *testing.T) {
+	t.Run("set", func(t *testing.T) {
+		ctx := NewContext(context.Background())
+
+		SetRouteMetadata(ctx, map[string]any{"foo": "bar"})
+		actual, ok := GetRouteMetadata(ctx, "foo")
+		require.True(t, ok)
+		require.Equal(t, "bar", actual)
+
+		_, ok = GetRouteMetadata(ctx, "bar")
+		require.False(t, ok)
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		ctx := NewContext(context.Background())
+
+		SetRouteMetadata(ctx, map[string]any{"foo": "bar"})
+		ResetContext(ctx)
+
+		_, ok := GetRouteMetadata(ctx, "foo")
+		require.False(t, ok)
+	})
+}
+
 func TestURLParams(t *testing.T) {
 	t.Run("set", func(t *testing.T) {
 		ctx := NewContext(context.Background())
@@ -354,3 +431,54 @@ func TestParseURLParams(t *testing.T) {
 		require.Empty(t, actual.skipField)
 	})
 }
+
+func TestAPIVersion(t *testing.T) {
+	ctx := NewContext(context.Background())
+
+	SetAPIVersion(ctx, "v2")
+	require.Equal(t, "v2", GetAPIVersion(ctx))
+
+	ResetContext(ctx)
+	require.Empty(t, GetAPIVersion(ctx))
+}
+
+func TestRoutePattern(t *testing.T) {
+	ctx := NewContext(context.Background())
+
+	SetRoutePattern(ctx, "/teams/{id}")
+	require.Equal(t, "/teams/{id}", GetRoutePattern(ctx))
+
+	ResetContext(ctx)
+	require.Empty(t, GetRoutePattern(ctx))
+}
+
+func TestResourceIDParam(t *testing.T) {
+	ctx := NewContext(context.Background())
+
+	SetResourceIDParam(ctx, "teamId")
+	require.Equal(t, "teamId", GetResourceIDParam(ctx))
+
+	ResetContext(ctx)
+	require.Empty(t, GetResourceIDParam(ctx))
+}
+
+func TestRequestID(t *testing.T) {
+	ctx := context.Background()
+	require.Empty(t, GetRequestID(ctx))
+
+	ctx = WithRequestID(NewContext(ctx), "abc")
+	require.Equal(t, "abc", GetRequestID(ctx))
+
+	ResetContext(ctx)
+	require.Equal(t, "abc", GetRequestID(ctx))
+}
+
+func TestAllowedMethods(t *testing.T) {
+	ctx := NewContext(context.Background())
+
+	SetAllowedMethods(ctx, []string{"GET", "PUT"})
+	require.Equal(t, []string{"GET", "PUT"}, GetAllowedMethods(ctx))
+
+	ResetContext(ctx)
+	require.Empty(t, GetAllowedMethods(ctx))
+}
@@ -0,0 +1,125 @@
+package limi
+
+import (
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MediaRange is a media range in the Accept header.
+type MediaRange struct {
+	Type    string
+	Subtype string
+	Params  map[string]string
+	Q       float64
+}
+
+// ParseAccept parses the Accept header into a list of media ranges, sorted by quality and specificity.
+// Invalid media ranges are ignored.
+func ParseAccept(accept string) []MediaRange {
+	var ranges []MediaRange
+	for _, s := range strings.Split(accept, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		mr, ok := ParseMediaRange(s)
+		if !ok {
+			continue
+		}
+		ranges = append(ranges, mr)
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].Q != ranges[j].Q {
+			return ranges[i].Q > ranges[j].Q
+		}
+		return ranges[i].specificity() > ranges[j].specificity()
+	})
+	return ranges
+}
+
+// ParseMediaRange parses a media range or media type with parameters, the q parameter is parsed as quality.
+func ParseMediaRange(s string) (MediaRange, bool) {
+	mediaType, params, err := mime.ParseMediaType(s)
+	if err != nil {
+		return MediaRange{}, false
+	}
+
+	typ, subtype, ok := strings.Cut(mediaType, "/")
+	if !ok || typ == "" || subtype == "" {
+		return MediaRange{}, false
+	}
+
+	mr := MediaRange{
+		Type:    typ,
+		Subtype: subtype,
+		Q:       1,
+	}
+
+	if q, ok := params["q"]; ok {
+		delete(params, "q")
+		f, err := strconv.ParseFloat(q, 64)
+		if err != nil || f < 0 || f > 1 {
+			return MediaRange{}, false
+		}
+		mr.Q = f
+	}
+
+	if len(params) > 0 {
+		mr.Params = params
+	}
+	return mr, true
+}
+
+// String returns the media type of the media range.
+func (m MediaRange) String() string {
+	return m.Type + "/" + m.Subtype
+}
+
+// Match returns true when the media range includes media type mt.
+func (m MediaRange) Match(mt MediaRange) bool {
+	if m.Type != "*" && m.Type != mt.Type {
+		return false
+	}
+	if m.Subtype != "*" && m.Subtype != mt.Subtype {
+		return false
+	}
+	for k, v := range m.Params {
+		if !strings.EqualFold(mt.Params[k], v) {
+			return false
+		}
+	}
+	return true
+}
+
+// specificity returns the precedence of the media range, more specific ranges override less specific ranges.
+func (m MediaRange) specificity() int {
+	switch {
+	case m.Type == "*":
+		return 0
+	case m.Subtype == "*":
+		return 1
+	default:
+		return 2 + len(m.Params)
+	}
+}
+
+// AcceptQuality returns the quality of media type mt in the list of media ranges,
+// the quality is taken from the most specific media range including mt.
+func AcceptQuality(ranges []MediaRange, mt MediaRange) float64 {
+	q := 0.0
+	specificity := -1
+	for _, r := range ranges {
+		if !r.Match(mt) {
+			continue
+		}
+		if s := r.specificity(); s > specificity {
+			q = r.Q
+			specificity = s
+		}
+	}
+	return q
+}
@@ -0,0 +1,48 @@
+package limi
+
+import (
+	"testing"
+
+	"github.com/sanekee/limi/internal/testing/require"
+)
+
+func TestParseAccept(t *testing.T) {
+	t.Run("sorted by quality and specificity", func(t *testing.T) {
+		ranges := ParseAccept("*/*;q=0.1, text/*, application/json;q=0.9, text/html")
+
+		var actual []string
+		for _, r := range ranges {
+			actual = append(actual, r.String())
+		}
+		require.Equal(t, []string{"text/html", "text/*", "application/json", "*/*"}, actual)
+	})
+
+	t.Run("invalid ranges", func(t *testing.T) {
+		ranges := ParseAccept("text, application/json;q=2, , text/html")
+		require.Len(t, ranges, 1)
+		require.Equal(t, "text/html", ranges[0].String())
+	})
+
+	t.Run("params", func(t *testing.T) {
+		ranges := ParseAccept("application/json; version=2; q=0.5")
+		require.Len(t, ranges, 1)
+		require.Equal(t, map[string]string{"version": "2"}, ranges[0].Params)
+		require.Equal(t, 0.5, ranges[0].Q)
+	})
+}
+
+func TestAcceptQuality(t *testing.T) {
+	ranges := ParseAccept("application/*;q=0.5, application/xml;q=0, application/json;version=2, */*;q=0.1")
+
+	mt := func(s string) MediaRange {
+		m, ok := ParseMediaRange(s)
+		require.True(t, ok)
+		return m
+	}
+
+	require.Equal(t, 0.5, AcceptQuality(ranges, mt("application/json")))
+	require.Equal(t, 1.0, AcceptQuality(ranges, mt("application/json;version=2")))
+	require.Equal(t, 0.0, AcceptQuality(ranges, mt("application/xml")))
+	require.Equal(t, 0.1, AcceptQuality(ranges, mt("text/plain")))
+	require.Equal(t, 0.0, AcceptQuality(ParseAccept("text/html"), mt("application/json")))
+}
@@ -0,0 +1,122 @@
+package limi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Route metadata keys of the route options declared in the handler's limi struct tag.
+const (
+	MetadataBodyLimit = "bodyLimit"
+	MetadataTimeout   = "timeout"
+	MetadataRateLimit = "rateLimit"
+)
+
+// byteUnits is the list of byte size units, in binary multiples.
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"KIB", 1 << 10},
+	{"MIB", 1 << 20},
+	{"GIB", 1 << 30},
+	{"KB", 1 << 10},
+	{"MB", 1 << 20},
+	{"GB", 1 << 30},
+	{"K", 1 << 10},
+	{"M", 1 << 20},
+	{"G", 1 << 30},
+	{"B", 1},
+}
+
+// ParseByteSize parses a byte size with an optional unit, i.e. `1024`, `512KB`, `10MiB`, `1G`.
+// Units are binary multiples.
+func ParseByteSize(str string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(str))
+	size := int64(1)
+	for _, u := range byteUnits {
+		if strings.HasSuffix(s, u.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, u.suffix))
+			size = u.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 || n > (1<<63-1)/size {
+		return 0, fmt.Errorf("invalid byte size %q %w", str, ErrInvalidInput)
+	}
+	return n * size, nil
+}
+
+// ByteSize returns the byte size of the metadata value, an integer or a string parsed by ParseByteSize.
+func ByteSize(v any) (int64, bool) {
+	switch t := v.(type) {
+	case int:
+		return int64(t), true
+	case int64:
+		return t, true
+	case int32:
+		return int64(t), true
+	case uint32:
+		return int64(t), true
+	case string:
+		n, err := ParseByteSize(t)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// Duration returns the duration of the metadata value, a time.Duration or a string parsed by time.ParseDuration.
+func Duration(v any) (time.Duration, bool) {
+	switch t := v.(type) {
+	case time.Duration:
+		return t, true
+	case string:
+		d, err := time.ParseDuration(strings.TrimSpace(t))
+		return d, err == nil
+	}
+	return 0, false
+}
+
+// Rate is a rate limit of Limit requests per Period.
+type Rate struct {
+	Limit  int
+	Period time.Duration
+}
+
+// ParseRate parses a rate of requests per period, i.e. `100/1m`, `10/s`, `1000/h`.
+func ParseRate(str string) (Rate, error) {
+	n, period, ok := strings.Cut(strings.TrimSpace(str), "/")
+	if !ok {
+		return Rate{}, fmt.Errorf("invalid rate %q %w", str, ErrInvalidInput)
+	}
+	period = strings.TrimSpace(period)
+	if period != "" && (period[0] < '0' || period[0] > '9') {
+		period = "1" + period
+	}
+
+	limit, err := strconv.Atoi(strings.TrimSpace(n))
+	if err != nil || limit <= 0 {
+		return Rate{}, fmt.Errorf("invalid rate %q %w", str, ErrInvalidInput)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Rate{}, fmt.Errorf("invalid rate %q %w", str, ErrInvalidInput)
+	}
+	return Rate{Limit: limit, Period: d}, nil
+}
+
+// RateOf returns the rate of the metadata value, a Rate or a string parsed by ParseRate.
+func RateOf(v any) (Rate, bool) {
+	switch t := v.(type) {
+	case Rate:
+		return t, t.Limit > 0 && t.Period > 0
+	case string:
+		r, err := ParseRate(t)
+		return r, err == nil
+	}
+	return Rate{}, false
+}
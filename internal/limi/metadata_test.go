@@ -0,0 +1,94 @@
+package limi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sanekee/limi/internal/testing/require"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]int64{
+		"0":       0,
+		"1024":    1024,
+		"1024B":   1024,
+		"512kb":   512 << 10,
+		"10MiB":   10 << 20,
+		" 1 GB ":  1 << 30,
+		"2g":      2 << 30,
+		"3K":      3 << 10,
+		"100m":    100 << 20,
+		"1023kib": 1023 << 10,
+	}
+	for str, expected := range tests {
+		n, err := ParseByteSize(str)
+		require.NoError(t, err)
+		require.Equal(t, expected, n)
+	}
+
+	for _, str := range []string{"", "MB", "-1", "1.5MB", "1TB", "9999999999999GB"} {
+		_, err := ParseByteSize(str)
+		require.Error(t, err)
+	}
+}
+
+func TestByteSize(t *testing.T) {
+	for _, v := range []any{1024, int64(1024), int32(1024), uint32(1024), "1KB"} {
+		n, ok := ByteSize(v)
+		require.True(t, ok)
+		require.Equal(t, int64(1024), n)
+	}
+
+	for _, v := range []any{nil, "invalid", 1.5} {
+		_, ok := ByteSize(v)
+		require.False(t, ok)
+	}
+}
+
+func TestDuration(t *testing.T) {
+	for _, v := range []any{2 * time.Second, "2s", " 2000ms "} {
+		d, ok := Duration(v)
+		require.True(t, ok)
+		require.Equal(t, 2*time.Second, d)
+	}
+
+	for _, v := range []any{nil, "2", 2} {
+		_, ok := Duration(v)
+		require.False(t, ok)
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		str      string
+		expected Rate
+	}{
+		{"100/1m", Rate{Limit: 100, Period: time.Minute}},
+		{"10/s", Rate{Limit: 10, Period: time.Second}},
+		{" 1000 / h ", Rate{Limit: 1000, Period: time.Hour}},
+		{"5/30s", Rate{Limit: 5, Period: 30 * time.Second}},
+	}
+	for _, tc := range tests {
+		r, err := ParseRate(tc.str)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, r)
+	}
+
+	for _, str := range []string{"", "100", "0/s", "-1/s", "1.5/s", "10/", "10/0s", "10/-1s", "10/x"} {
+		_, err := ParseRate(str)
+		require.Error(t, err)
+	}
+}
+
+func TestRateOf(t *testing.T) {
+	for _, v := range []any{Rate{Limit: 10, Period: time.Second}, "10/s"} {
+		r, ok := RateOf(v)
+		require.True(t, ok)
+		require.Equal(t, Rate{Limit: 10, Period: time.Second}, r)
+	}
+
+	for _, v := range []any{nil, "10", 10, Rate{}} {
+		_, ok := RateOf(v)
+		require.False(t, ok)
+	}
+}
@@ -163,11 +163,16 @@ func (n *Node) walk(level int, fn func(level int, str string, h any)) {
 }
 
 func (n *Node) Lookup(ctx context.Context, str string) (Handle, string) {
-	return lookup(ctx, n, str)
+	return lookup(ctx, n, str, false)
 
 }
 
-func lookup(ctx context.Context, n *Node, str string) (Handle, string) {
+// LookupFold lookups str with case insensitive string matchers.
+func (n *Node) LookupFold(ctx context.Context, str string) (Handle, string) {
+	return lookup(ctx, n, str, true)
+}
+
+func lookup(ctx context.Context, n *Node, str string, fold bool) (Handle, string) {
 	if str == "" {
 		return nil, ""
 	}
@@ -176,7 +181,13 @@ func lookup(ctx context.Context, n *Node, str string) (Handle, string) {
 		return nil, str
 	}
 
-	isMatched, matched, trail := n.matcher.Match(str)
+	var isMatched bool
+	var matched, trail string
+	if sm, ok := n.matcher.(*StringMatcher); ok && fold {
+		isMatched, matched, trail = sm.MatchFold(str)
+	} else {
+		isMatched, matched, trail = n.matcher.Match(str)
+	}
 
 	if isMatched &&
 		n.matcher.Label() != "" && len(matched) > 0 {
@@ -194,7 +205,7 @@ func lookup(ctx context.Context, n *Node, str string) (Handle, string) {
 
 	// lookup partial match
 	for _, nn := range n.children {
-		h, trail := lookup(ctx, nn, trail)
+		h, trail := lookup(ctx, nn, trail, fold)
 		if h != nil {
 			return h, trail
 		}
@@ -210,6 +221,47 @@ func lookup(ctx context.Context, n *Node, str string) (Handle, string) {
 
 }
 
+// LookupAll lookups str and calls fn with every handle fully matching str.
+// Unlike Lookup, all matching branches are visited and the URL params of a match are passed to fn instead of the context.
+func (n *Node) LookupAll(str string, fn func(h Handle, params map[string]string)) {
+	lookupAll(n, str, nil, fn)
+}
+
+// lookupAll visits the matching branches of n, labels are the label and value pairs matched by the parent nodes.
+func lookupAll(n *Node, str string, labels []string, fn func(Handle, map[string]string)) {
+	if str == "" || n.matcher == nil {
+		return
+	}
+
+	isMatched, matched, trail := n.matcher.Match(str)
+	if !isMatched {
+		return
+	}
+
+	if n.matcher.Label() != "" && len(matched) > 0 {
+		// copy on append, labels are shared by the sibling branches
+		labels = append(labels[:len(labels):len(labels)], n.matcher.Label(), matched)
+	}
+
+	if trail == "" {
+		if n.handle != nil {
+			var params map[string]string
+			if len(labels) > 0 {
+				params = make(map[string]string, len(labels)/2)
+				for i := 0; i < len(labels); i += 2 {
+					params[labels[i]] = labels[i+1]
+				}
+			}
+			fn(n.handle, params)
+		}
+		return
+	}
+
+	for _, nn := range n.children {
+		lookupAll(nn, trail, labels, fn)
+	}
+}
+
 type nodes []*Node
 
 func (n nodes) Less(i, j int) bool {
@@ -3,6 +3,7 @@ package limi
 import (
 	"context"
 	"net/http"
+	"sort"
 	"testing"
 	"time"
 
@@ -890,6 +891,69 @@ func TestRegexpRoute(t *testing.T) {
 	})
 }
 
+func TestLookupFold(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	ctx = NewContext(ctx)
+
+	root := &Node{}
+
+	err := root.Insert("/foo/{id}/bar", funcHandler(func() string { return "i'm /foo/{id}/bar" }))
+	require.NoError(t, err)
+
+	h1 := lookupFunc(root.Lookup(ctx, "/FOO/Abc/Bar"))
+	require.Nil(t, h1)
+
+	h2 := lookupFunc(root.LookupFold(ctx, "/FOO/Abc/Bar"))
+	require.NotNil(t, h2)
+	require.Equal(t, "i'm /foo/{id}/bar", h2())
+
+	id := GetURLParam(ctx, "id")
+	require.Equal(t, "Abc", id)
+}
+
+func TestLookupAll(t *testing.T) {
+	// hosts share the root "." node
+	root := &Node{}
+
+	err := root.Insert(".tenant1.example.com", funcHandler(func() string { return "tenant1" }))
+	require.NoError(t, err)
+
+	err = root.Insert(".{subdomain}.example.com", funcHandler(func() string { return "subdomain" }))
+	require.NoError(t, err)
+
+	err = root.Insert(".{region:eu-[0-9]+}.example.com", funcHandler(func() string { return "region" }))
+	require.NoError(t, err)
+
+	lookupAll := func(str string) []string {
+		var ret []string
+		root.LookupAll(str, func(h Handle, params map[string]string) {
+			ret = append(ret, h.(funcHandler)())
+		})
+		sort.Strings(ret)
+		return ret
+	}
+
+	require.Equal(t, []string{"subdomain", "tenant1"}, lookupAll(".tenant1.example.com"))
+	require.Equal(t, []string{"region", "subdomain"}, lookupAll(".eu-1.example.com"))
+	require.Equal(t, []string{"subdomain"}, lookupAll(".tenant2.example.com"))
+	require.Empty(t, lookupAll(".tenant1.example.org"))
+
+	params := map[string]map[string]string{}
+	root.LookupAll(".eu-1.example.com", func(h Handle, p map[string]string) {
+		params[h.(funcHandler)()] = p
+	})
+	require.Equal(t, map[string]string{"region": "eu-1"}, params["region"])
+	require.Equal(t, map[string]string{"subdomain": "eu-1"}, params["subdomain"])
+
+	root.LookupAll(".tenant1.example.com", func(h Handle, p map[string]string) {
+		if h.(funcHandler)() == "tenant1" {
+			require.Nil(t, p)
+		}
+	})
+}
+
 func buildTree(n *Node) *routePath {
 	if n == nil {
 		return nil
@@ -1,5 +1,7 @@
 package limi
 
+import "strings"
+
 type StringMatcher struct {
 	data string
 }
@@ -32,6 +34,16 @@ func (s *StringMatcher) Match(str string) (bool, string, string) {
 
 }
 
+// MatchFold matches str with the string under case folding.
+func (s *StringMatcher) MatchFold(str string) (bool, string, string) {
+	if len(str) < len(s.data) ||
+		!strings.EqualFold(str[:len(s.data)], s.data) {
+		return false, "", str
+	}
+
+	return true, str[:len(s.data)], str[len(s.data):]
+}
+
 func (s *StringMatcher) Parse(p Parser) (bool, string, string, string) {
 	if TypeString != p.Type {
 		return false, "", p.Str, s.data
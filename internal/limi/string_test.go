@@ -91,3 +91,35 @@ func TestStringMatch(t *testing.T) {
 		require.Empty(t, matched)
 	})
 }
+
+func TestStringMatchFold(t *testing.T) {
+	t.Run("exact matched", func(t *testing.T) {
+		s := NewStringMatcher("/foo")
+
+		isMatched, matched, trail := s.MatchFold("/FoO")
+		require.True(t, isMatched)
+		require.Equal(t, "/FoO", matched)
+		require.Empty(t, trail)
+	})
+
+	t.Run("partial matched", func(t *testing.T) {
+		s := NewStringMatcher("/foo")
+
+		isMatched, matched, trail := s.MatchFold("/FOO/bar")
+		require.True(t, isMatched)
+		require.Equal(t, "/FOO", matched)
+		require.Equal(t, "/bar", trail)
+	})
+
+	t.Run("not matched", func(t *testing.T) {
+		s := NewStringMatcher("/foo")
+
+		isMatched, matched, trail := s.MatchFold("/fo")
+		require.False(t, isMatched)
+		require.Empty(t, matched)
+		require.Equal(t, "/fo", trail)
+
+		isMatched, _, _ = s.MatchFold("/bar")
+		require.False(t, isMatched)
+	})
+}
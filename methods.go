@@ -0,0 +1,114 @@
+package limi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sanekee/limi/internal/limi"
+)
+
+// standardMethods is the list of http methods defined in RFC 9110 and RFC 5789.
+var standardMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodConnect,
+	http.MethodOptions,
+	http.MethodTrace,
+}
+
+// WithCustomMethods set Router's list of custom http methods (e.g. `PURGE`, `PROPFIND`) allowed in addition to the standard methods.
+// Custom methods are matched case insensitively and normalized to upper case, as the standard methods.
+func WithCustomMethods(methods ...string) RouterOptions {
+	return func(r *Router) error {
+		for _, m := range methods {
+			m = strings.TrimSpace(m)
+			if !isToken(m) {
+				return fmt.Errorf("invalid custom method %q %w", m, limi.ErrInvalidInput)
+			}
+			if r.customMethods == nil {
+				r.customMethods = make(map[string]struct{})
+			}
+			r.customMethods[strings.ToUpper(m)] = struct{}{}
+		}
+		return nil
+	}
+}
+
+// normalizeMethods returns the list of validated methods.
+// Standard and custom methods are matched case insensitively and normalized to upper case, duplicated methods are removed.
+func normalizeMethods(methods []string, customMethods map[string]struct{}) ([]string, error) {
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("missing method %w", limi.ErrInvalidInput)
+	}
+
+	var ret []string
+	seen := make(map[string]struct{})
+	for _, m := range methods {
+		nm, err := normalizeMethod(m, customMethods)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[nm]; ok {
+			continue
+		}
+		seen[nm] = struct{}{}
+		ret = append(ret, nm)
+	}
+	return ret, nil
+}
+
+// normalizeMethod returns the normalized method or error if method is not a valid token or not supported.
+func normalizeMethod(method string, customMethods map[string]struct{}) (string, error) {
+	m := strings.TrimSpace(method)
+	if !isToken(m) {
+		return "", fmt.Errorf("invalid method %q %w", method, limi.ErrInvalidInput)
+	}
+
+	upper := strings.ToUpper(m)
+	if isStandardMethod(upper) {
+		return upper, nil
+	}
+	if _, ok := customMethods[upper]; ok {
+		return upper, nil
+	}
+	return "", fmt.Errorf("unsupported method %q, custom method must be set with WithCustomMethods %w", method, limi.ErrInvalidInput)
+}
+
+// isStandardMethod returns true if method is one of the standard methods.
+func isStandardMethod(method string) bool {
+	for _, m := range standardMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// isToken returns true if str is a valid RFC 9110 token.
+func isToken(str string) bool {
+	if str == "" {
+		return false
+	}
+	for i := 0; i < len(str); i++ {
+		if !isTokenChar(str[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isTokenChar returns true if c is a RFC 9110 tchar.
+func isTokenChar(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z',
+		c >= 'A' && c <= 'Z',
+		c >= '0' && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
@@ -0,0 +1,109 @@
+package limi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/sanekee/limi/internal/testing/require"
+)
+
+func TestNormalizeMethods(t *testing.T) {
+	type test struct {
+		testName      string
+		methods       []string
+		customMethods map[string]struct{}
+		expected      []string
+		isError       bool
+	}
+
+	tests := []test{
+		{
+			testName: "standard method",
+			methods:  []string{"GET"},
+			expected: []string{"GET"},
+		},
+		{
+			testName: "lower case standard method",
+			methods:  []string{"get", " Post "},
+			expected: []string{"GET", "POST"},
+		},
+		{
+			testName: "duplicated methods",
+			methods:  []string{"GET", "get"},
+			expected: []string{"GET"},
+		},
+		{
+			testName:      "custom method",
+			methods:       []string{"purge", "PROPFIND"},
+			customMethods: map[string]struct{}{"PURGE": {}, "PROPFIND": {}},
+			expected:      []string{"PURGE", "PROPFIND"},
+		},
+		{
+			testName: "unknown method",
+			methods:  []string{"PURGE"},
+			isError:  true,
+		},
+		{
+			testName: "invalid token",
+			methods:  []string{"GE T"},
+			isError:  true,
+		},
+		{
+			testName: "empty method",
+			methods:  []string{""},
+			isError:  true,
+		},
+		{
+			testName: "no method",
+			isError:  true,
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			actual, err := normalizeMethods(tt.methods, tt.customMethods)
+			if tt.isError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestIsToken(t *testing.T) {
+	require.True(t, isToken("GET"))
+	require.True(t, isToken("VERSION-CONTROL"))
+	require.True(t, isToken("M!#$%&'*+-.^_`|~1"))
+	require.False(t, isToken(""))
+	require.False(t, isToken("GET/"))
+	require.False(t, isToken("GET\n"))
+	require.False(t, isToken("(GET)"))
+}
+
+func TestAllowedMethods(t *testing.T) {
+	var got []string
+	r, err := NewRouter("/", WithMiddlewares(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			next.ServeHTTP(w, req)
+			got = AllowedMethods(req.Context())
+		})
+	}))
+	require.NoError(t, err)
+
+	noop := func(w http.ResponseWriter, req *http.Request) {}
+	require.NoError(t, r.Get("/items", noop))
+	require.NoError(t, r.Put("/items", noop))
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodOptions, "/items", nil))
+	sort.Strings(got)
+	require.Equal(t, []string{http.MethodGet, http.MethodPut}, got)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items", nil))
+	require.Empty(t, got)
+}
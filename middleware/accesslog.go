@@ -0,0 +1,306 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sanekee/limi/internal/limi"
+)
+
+// AccessLogFormat is the format of the access log lines,
+// a template with the tokens below, or JSONFormat for JSON lines.
+//   - `%h` remote ip, `%l` remote logname (always `-`), `%u` basic auth user.
+//   - `%t` request time, i.e. `[10/Oct/2000:13:55:36 -0700]`.
+//   - `%r` request line, `%m` method, `%U` path, `%q` query string, `%H` protocol, `%R` route pattern.
+//   - `%s` or `%>s` status, `%b` response bytes (`-` when 0), `%B` response bytes.
+//   - `%D` latency in microseconds, `%T` latency in seconds.
+//   - `%{Name}i` request header, `%{Name}o` response header.
+//   - `%%` a literal `%`.
+type AccessLogFormat string
+
+const (
+	// CommonFormat is the NCSA Common Log Format.
+	CommonFormat AccessLogFormat = `%h %l %u %t "%r" %>s %b`
+	// CombinedFormat is the NCSA Combined Log Format.
+	CombinedFormat AccessLogFormat = `%h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-Agent}i"`
+	// JSONFormat is the JSON lines format.
+	JSONFormat AccessLogFormat = "json"
+)
+
+const accessLogTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// AccessLogOptions is the options of AccessLog.
+type AccessLogOptions struct {
+	// Format is the format of the access log lines, default is CombinedFormat.
+	Format AccessLogFormat
+}
+
+// accessLogEntry is the request and response of an access log line.
+type accessLogEntry struct {
+	req     *http.Request
+	header  http.Header
+	start   time.Time
+	latency time.Duration
+	status  int
+	bytes   int64
+	route   string
+}
+
+// accessLogToken appends the token's value of the entry to b.
+type accessLogToken func(b []byte, e *accessLogEntry) []byte
+
+// AccessLog middleware writes an access log line of each request to w, in the format of the options.
+// Writes to w are serialized, wrap w with NewAsyncWriter to avoid blocking the requests on slow writers.
+func AccessLog(w io.Writer, opts AccessLogOptions) (func(http.Handler) http.Handler, error) {
+	format := opts.Format
+	if format == "" {
+		format = CombinedFormat
+	}
+
+	var encode func(b []byte, e *accessLogEntry) []byte
+	if format == JSONFormat {
+		encode = encodeJSONAccessLog
+	} else {
+		tokens, err := parseAccessLogFormat(string(format))
+		if err != nil {
+			return nil, err
+		}
+		encode = func(b []byte, e *accessLogEntry) []byte {
+			for _, t := range tokens {
+				b = t(b, e)
+			}
+			return b
+		}
+	}
+
+	var mu sync.Mutex
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			recorder := WrapResponseWriter(rw)
+			next.ServeHTTP(recorder, req)
+
+			e := accessLogEntry{
+				req:     req,
+				header:  recorder.WrittenHeader(),
+				start:   start,
+				latency: time.Since(start),
+				status:  recorder.Status(),
+				bytes:   recorder.BytesWritten(),
+				route:   limi.GetRoutePattern(req.Context()),
+			}
+			if e.status == 0 {
+				// the response is written by the server after the handler returned
+				e.status = http.StatusOK
+				e.header = rw.Header()
+			}
+
+			line := append(encode(make([]byte, 0, 256), &e), '\n')
+
+			mu.Lock()
+			defer mu.Unlock()
+			w.Write(line) // nolint:errcheck
+		})
+	}, nil
+}
+
+// parseAccessLogFormat parses the access log format into tokens.
+func parseAccessLogFormat(format string) ([]accessLogToken, error) {
+	var tokens []accessLogToken
+	var literal strings.Builder
+	flushLiteral := func() {
+		if literal.Len() == 0 {
+			return
+		}
+		str := literal.String()
+		literal.Reset()
+		tokens = append(tokens, func(b []byte, e *accessLogEntry) []byte {
+			return append(b, str...)
+		})
+	}
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			literal.WriteByte(format[i])
+			continue
+		}
+
+		i++
+		if i >= len(format) {
+			return nil, fmt.Errorf("incomplete token at the end of format %q", format)
+		}
+
+		var name string
+		if format[i] == '{' {
+			end := strings.IndexByte(format[i:], '}')
+			if end < 0 || i+end+1 >= len(format) {
+				return nil, fmt.Errorf("invalid header token in format %q", format)
+			}
+			name = format[i+1 : i+end]
+			i += end + 1
+		} else if format[i] == '>' && i+1 < len(format) && format[i+1] == 's' {
+			i++
+		}
+
+		c := format[i]
+		if c == '%' {
+			literal.WriteByte('%')
+			continue
+		}
+
+		token, ok := accessLogTokenOf(c, name)
+		if !ok {
+			return nil, fmt.Errorf("unsupported token %%%c in format %q", c, format)
+		}
+		flushLiteral()
+		tokens = append(tokens, token)
+	}
+	flushLiteral()
+	return tokens, nil
+}
+
+// accessLogTokenOf returns the token of the format character, with the header name of the `%{Name}i` and `%{Name}o` tokens.
+func accessLogTokenOf(c byte, name string) (accessLogToken, bool) {
+	switch c {
+	case 'i':
+		return func(b []byte, e *accessLogEntry) []byte {
+			return appendLogValue(b, e.req.Header.Get(name))
+		}, name != ""
+	case 'o':
+		return func(b []byte, e *accessLogEntry) []byte {
+			return appendLogValue(b, e.header.Get(name))
+		}, name != ""
+	case 'h':
+		return func(b []byte, e *accessLogEntry) []byte {
+			return appendLogValue(b, remoteIP(e.req.RemoteAddr))
+		}, true
+	case 'l':
+		return func(b []byte, e *accessLogEntry) []byte {
+			return append(b, '-')
+		}, true
+	case 'u':
+		return func(b []byte, e *accessLogEntry) []byte {
+			user, _, _ := e.req.BasicAuth()
+			return appendLogValue(b, user)
+		}, true
+	case 't':
+		return func(b []byte, e *accessLogEntry) []byte {
+			b = append(b, '[')
+			b = e.start.AppendFormat(b, accessLogTimeFormat)
+			return append(b, ']')
+		}, true
+	case 'r':
+		return func(b []byte, e *accessLogEntry) []byte {
+			return appendLogValue(b, e.req.Method+" "+requestURI(e.req)+" "+e.req.Proto)
+		}, true
+	case 'm':
+		return func(b []byte, e *accessLogEntry) []byte {
+			return appendLogValue(b, e.req.Method)
+		}, true
+	case 'U':
+		return func(b []byte, e *accessLogEntry) []byte {
+			return appendLogValue(b, e.req.URL.Path)
+		}, true
+	case 'q':
+		return func(b []byte, e *accessLogEntry) []byte {
+			if e.req.URL.RawQuery == "" {
+				return b
+			}
+			return appendLogValue(append(b, '?'), e.req.URL.RawQuery)
+		}, true
+	case 'H':
+		return func(b []byte, e *accessLogEntry) []byte {
+			return appendLogValue(b, e.req.Proto)
+		}, true
+	case 'R':
+		return func(b []byte, e *accessLogEntry) []byte {
+			return appendLogValue(b, e.route)
+		}, true
+	case 's':
+		return func(b []byte, e *accessLogEntry) []byte {
+			return strconv.AppendInt(b, int64(e.status), 10)
+		}, true
+	case 'b':
+		return func(b []byte, e *accessLogEntry) []byte {
+			if e.bytes == 0 {
+				return append(b, '-')
+			}
+			return strconv.AppendInt(b, e.bytes, 10)
+		}, true
+	case 'B':
+		return func(b []byte, e *accessLogEntry) []byte {
+			return strconv.AppendInt(b, e.bytes, 10)
+		}, true
+	case 'D':
+		return func(b []byte, e *accessLogEntry) []byte {
+			return strconv.AppendInt(b, e.latency.Microseconds(), 10)
+		}, true
+	case 'T':
+		return func(b []byte, e *accessLogEntry) []byte {
+			return strconv.AppendInt(b, int64(e.latency/time.Second), 10)
+		}, true
+	}
+	return nil, false
+}
+
+// appendLogValue appends the escaped value to b, `-` when value is empty.
+func appendLogValue(b []byte, value string) []byte {
+	if value == "" {
+		return append(b, '-')
+	}
+	quoted := strconv.Quote(value)
+	return append(b, quoted[1:len(quoted)-1]...)
+}
+
+// requestURI returns the request URI of the request line.
+func requestURI(req *http.Request) string {
+	if req.RequestURI != "" {
+		return req.RequestURI
+	}
+	return req.URL.RequestURI()
+}
+
+// jsonAccessLog is the JSON line of an access log entry.
+type jsonAccessLog struct {
+	Time      string  `json:"time"`
+	RemoteIP  string  `json:"remote_ip"`
+	User      string  `json:"user,omitempty"`
+	Method    string  `json:"method"`
+	URI       string  `json:"uri"`
+	Route     string  `json:"route,omitempty"`
+	Proto     string  `json:"proto"`
+	Status    int     `json:"status"`
+	Bytes     int64   `json:"bytes"`
+	LatencyMS float64 `json:"latency_ms"`
+	Referer   string  `json:"referer,omitempty"`
+	UserAgent string  `json:"user_agent,omitempty"`
+}
+
+// encodeJSONAccessLog appends the JSON line of the entry to b.
+func encodeJSONAccessLog(b []byte, e *accessLogEntry) []byte {
+	user, _, _ := e.req.BasicAuth()
+	data, err := json.Marshal(jsonAccessLog{
+		Time:      e.start.Format(time.RFC3339Nano),
+		RemoteIP:  remoteIP(e.req.RemoteAddr),
+		User:      user,
+		Method:    e.req.Method,
+		URI:       requestURI(e.req),
+		Route:     e.route,
+		Proto:     e.req.Proto,
+		Status:    e.status,
+		Bytes:     e.bytes,
+		LatencyMS: float64(e.latency.Microseconds()) / 1000,
+		Referer:   e.req.Referer(),
+		UserAgent: e.req.UserAgent(),
+	})
+	if err != nil {
+		return b
+	}
+	return append(b, data...)
+}
@@ -0,0 +1,233 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sanekee/limi"
+	"github.com/sanekee/limi/internal/testing/require"
+)
+
+func TestAccessLog(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Cache", "hit")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello")) // nolint:errcheck
+	})
+
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/items?q=1", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.SetBasicAuth("frank", "secret")
+		req.Header.Set("Referer", "http://example.com/")
+		req.Header.Set("User-Agent", `curl "7"`)
+		return req
+	}
+
+	serve := func(t *testing.T, opts AccessLogOptions) string {
+		var buf bytes.Buffer
+		mw, err := AccessLog(&buf, opts)
+		require.NoError(t, err)
+		mw(handler).ServeHTTP(httptest.NewRecorder(), newRequest())
+		return buf.String()
+	}
+
+	t.Run("common", func(t *testing.T) {
+		line := serve(t, AccessLogOptions{Format: CommonFormat})
+		re := regexp.MustCompile(`^10\.0\.0\.1 - frank \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "POST /items\?q=1 HTTP/1\.1" 201 5\n$`)
+		require.True(t, re.MatchString(line))
+	})
+
+	t.Run("combined", func(t *testing.T) {
+		line := serve(t, AccessLogOptions{})
+		require.True(t, strings.HasSuffix(line, `201 5 "http://example.com/" "curl \"7\""`+"\n"))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		line := serve(t, AccessLogOptions{Format: JSONFormat})
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		require.Equal(t, "10.0.0.1", entry["remote_ip"])
+		require.Equal(t, "frank", entry["user"])
+		require.Equal(t, "POST", entry["method"])
+		require.Equal(t, "/items?q=1", entry["uri"])
+		require.Equal(t, float64(201), entry["status"])
+		require.Equal(t, float64(5), entry["bytes"])
+		require.Equal(t, `curl "7"`, entry["user_agent"])
+	})
+
+	t.Run("template", func(t *testing.T) {
+		line := serve(t, AccessLogOptions{Format: "%m %U%q %s %B %{X-Cache}o %{X-Missing}i 100%%"})
+		require.Equal(t, "POST /items?q=1 201 5 hit - 100%\n", line)
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		for _, format := range []AccessLogFormat{"%", "%z", "%{Referer", "%{}i"} {
+			_, err := AccessLog(&bytes.Buffer{}, AccessLogOptions{Format: format})
+			require.Error(t, err)
+		}
+	})
+
+	t.Run("route", func(t *testing.T) {
+		var buf bytes.Buffer
+		mw, err := AccessLog(&buf, AccessLogOptions{Format: "%R %s %b"})
+		require.NoError(t, err)
+
+		r, err := limi.NewRouter("/", limi.WithMiddlewares(mw))
+		require.NoError(t, err)
+		require.NoError(t, r.Get("/items/{id}", func(w http.ResponseWriter, req *http.Request) {}))
+
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items/1", nil))
+		require.Equal(t, "/items/{id} 200 -\n", buf.String())
+	})
+}
+
+func TestAsyncWriter(t *testing.T) {
+	t.Run("flush", func(t *testing.T) {
+		var buf safeBuffer
+		w := NewAsyncWriter(&buf, AsyncWriterOptions{FlushInterval: time.Hour})
+
+		_, err := w.Write([]byte("foo\n"))
+		require.NoError(t, err)
+		require.Equal(t, "", buf.String())
+
+		require.NoError(t, w.Flush())
+		require.Equal(t, "foo\n", buf.String())
+
+		_, err = w.Write([]byte("bar\n"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		require.Equal(t, "foo\nbar\n", buf.String())
+
+		_, err = w.Write([]byte("baz\n"))
+		require.Error(t, err)
+		require.Error(t, w.Close())
+	})
+
+	t.Run("interval", func(t *testing.T) {
+		var buf safeBuffer
+		w := NewAsyncWriter(&buf, AsyncWriterOptions{FlushInterval: time.Millisecond})
+		defer w.Close()
+
+		_, err := w.Write([]byte("foo\n"))
+		require.NoError(t, err)
+
+		deadline := time.Now().Add(time.Second)
+		for buf.String() == "" && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		require.Equal(t, "foo\n", buf.String())
+	})
+}
+
+func TestRotatingFile(t *testing.T) {
+	t.Run("size", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "access.log")
+		f, err := NewRotatingFile(path, RotateOptions{MaxSize: 8, MaxBackups: 2})
+		require.NoError(t, err)
+
+		for _, s := range []string{"line1\n", "line2\n", "line3\n", "line4\n"} {
+			_, err := f.Write([]byte(s))
+			require.NoError(t, err)
+		}
+		require.NoError(t, f.Close())
+
+		b, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "line4\n", string(b))
+
+		matches, err := filepath.Glob(filepath.Join(dir, "access-*.log"))
+		require.NoError(t, err)
+		require.Len(t, matches, 2)
+
+		b, err = os.ReadFile(matches[1])
+		require.NoError(t, err)
+		require.Equal(t, "line3\n", string(b))
+	})
+
+	t.Run("interval", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "access.log")
+		f, err := NewRotatingFile(path, RotateOptions{Interval: time.Millisecond})
+		require.NoError(t, err)
+		defer f.Close()
+
+		time.Sleep(2 * time.Millisecond)
+		_, err = f.Write([]byte("line1\n"))
+		require.NoError(t, err)
+
+		matches, err := filepath.Glob(filepath.Join(dir, "access-*.log"))
+		require.NoError(t, err)
+		require.Len(t, matches, 1)
+	})
+
+	t.Run("rename failed", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "access.log")
+		f, err := NewRotatingFile(path, RotateOptions{})
+		require.NoError(t, err)
+		defer f.Close()
+
+		// the file is removed externally, failing to be renamed
+		require.NoError(t, os.Remove(path))
+		require.Error(t, f.Rotate())
+
+		_, err = f.Write([]byte("line1\n"))
+		require.NoError(t, err)
+		b, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "line1\n", string(b))
+	})
+
+	t.Run("reopen", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "access.log")
+		f, err := NewRotatingFile(path, RotateOptions{})
+		require.NoError(t, err)
+		require.NoError(t, f.file.Close())
+		f.file = nil
+
+		_, err = f.Write([]byte("line1\n"))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		_, err = f.Write([]byte("line2\n"))
+		require.True(t, errors.Is(err, ErrWriterClosed))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewRotatingFile("", RotateOptions{})
+		require.Error(t, err)
+		_, err = NewRotatingFile(filepath.Join(t.TempDir(), "access.log"), RotateOptions{MaxSize: -1})
+		require.Error(t, err)
+	})
+}
+
+// safeBuffer is a bytes.Buffer safe for concurrent use.
+type safeBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *safeBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *safeBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,237 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/sanekee/limi/internal/limi"
+	"github.com/sanekee/limi/render"
+)
+
+const (
+	defaultMaxRatio = 100
+	// minRatioCheckSize is the decompressed size before the ratio is checked, small bodies compress with high ratios.
+	minRatioCheckSize = 1 << 20
+)
+
+// DecompressOptions is the options of Decompress.
+type DecompressOptions struct {
+	// MaxRatio is the maximum ratio of the decompressed to the compressed size, default is 100.
+	// Reading a body exceeding the ratio returns *http.MaxBytesError.
+	MaxRatio int64
+}
+
+// Decompress middleware decodes the request body with the gzip or deflate `Content-Encoding`.
+// The `Content-Encoding` and `Content-Length` headers are removed from the decoded request.
+// Requests with other content codings are responded with 415 Unsupported Media Type, and invalid compressed bodies with 400 Bad Request.
+// Install Decompress before BodyLimit to limit the decompressed body size.
+func Decompress(opts DecompressOptions) func(http.Handler) http.Handler {
+	maxRatio := opts.MaxRatio
+	if maxRatio <= 0 {
+		maxRatio = defaultMaxRatio
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			encoding := strings.ToLower(strings.TrimSpace(req.Header.Get("Content-Encoding")))
+			if encoding == "" || encoding == "identity" || req.Body == nil || req.Body == http.NoBody {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			compressed := &countingReader{r: req.Body}
+			var body io.ReadCloser
+			var err error
+			switch encoding {
+			case "gzip", "x-gzip":
+				body, err = gzip.NewReader(compressed)
+			case "deflate":
+				body, err = zlib.NewReader(compressed)
+			default:
+				w.Header().Set("Accept-Encoding", "gzip, deflate")
+				render.WriteProblem(w, req, render.NewProblem(http.StatusUnsupportedMediaType, "unsupported content encoding "+encoding)) // nolint:errcheck
+				return
+			}
+			if err != nil {
+				render.WriteProblem(w, req, render.NewProblem(http.StatusBadRequest, "invalid "+encoding+" request body")) // nolint:errcheck
+				return
+			}
+
+			req = req.Clone(req.Context())
+			req.Body = &decompressReader{
+				body:       body,
+				orig:       req.Body,
+				compressed: compressed,
+				maxRatio:   maxRatio,
+			}
+			req.Header.Del("Content-Encoding")
+			req.Header.Del("Content-Length")
+			req.ContentLength = -1
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// countingReader counts the bytes read.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read implements io.Reader.
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// decompressReader is the decompressed request body, guarded by the decompression ratio.
+type decompressReader struct {
+	body       io.ReadCloser
+	orig       io.ReadCloser
+	compressed *countingReader
+	maxRatio   int64
+	n          int64
+	err        error
+}
+
+// Read implements io.Reader, returns *http.MaxBytesError when the decompression ratio is exceeded.
+func (d *decompressReader) Read(p []byte) (int, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+
+	n, err := d.body.Read(p)
+	d.n += int64(n)
+	if d.n > minRatioCheckSize && d.n > d.compressed.n*d.maxRatio {
+		d.err = &http.MaxBytesError{Limit: d.compressed.n * d.maxRatio}
+		return n, d.err
+	}
+	return n, err
+}
+
+// Close implements io.Closer, closes the decompressor and the original body.
+func (d *decompressReader) Close() error {
+	d.body.Close()
+	return d.orig.Close()
+}
+
+// BodyLimit middleware limits the request body size, the route's MetadataBodyLimit (i.e. `limi:"bodylimit=10MB"`) overrides limit.
+// The body is not limited when the limit is 0.
+// Requests with `Content-Length` exceeding the limit are responded with 413 Content Too Large,
+// otherwise reading the body beyond the limit returns *http.MaxBytesError, and the request is responded with 413 Content Too Large
+// when the handler has not written the response. The handler's response is discarded after the 413 response.
+func BodyLimit(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			n := limit
+			if v, ok := limi.GetRouteMetadata(req.Context(), limi.MetadataBodyLimit); ok {
+				if size, ok := limi.ByteSize(v); ok {
+					n = size
+				}
+			}
+			if n <= 0 || req.Body == nil || req.Body == http.NoBody {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			if req.ContentLength > n {
+				w.Header().Set("Connection", "close")
+				render.WriteProblem(w, req, bodyTooLarge(n)) // nolint:errcheck
+				return
+			}
+
+			bw := &bodyLimitWriter{ResponseWriter: WrapResponseWriter(w), req: req}
+			req.Body = &limitedBody{ReadCloser: http.MaxBytesReader(w, req.Body, n), w: bw}
+			next.ServeHTTP(wrapWriter(bw, w), req)
+		})
+	}
+}
+
+// limitedBody is the request body limited by BodyLimit, responding with 413 Content Too Large when the limit is exceeded.
+type limitedBody struct {
+	io.ReadCloser
+	w *bodyLimitWriter
+}
+
+// Read implements io.Reader.
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var maxBytesErr *http.MaxBytesError
+	if err != nil && errors.As(err, &maxBytesErr) {
+		b.w.tooLarge(maxBytesErr.Limit)
+	}
+	return n, err
+}
+
+// bodyLimitWriter discards the handler's response after the 413 Content Too Large response.
+// The embedded ResponseWriter records the response written to the underlying writer.
+type bodyLimitWriter struct {
+	ResponseWriter
+	req      *http.Request
+	exceeded bool
+}
+
+// tooLarge writes the 413 Content Too Large problem of limit, when the response has not started.
+func (bw *bodyLimitWriter) tooLarge(limit int64) {
+	if bw.exceeded || bw.Status() != 0 {
+		return
+	}
+	bw.exceeded = true
+	bw.Header().Set("Connection", "close")
+	render.WriteProblem(bw.ResponseWriter, bw.req, bodyTooLarge(limit)) // nolint:errcheck
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (bw *bodyLimitWriter) WriteHeader(statusCode int) {
+	if bw.exceeded {
+		return
+	}
+	bw.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write implements http.ResponseWriter.
+func (bw *bodyLimitWriter) Write(b []byte) (int, error) {
+	if bw.exceeded {
+		return len(b), nil
+	}
+	return bw.ResponseWriter.Write(b)
+}
+
+// flush implements http.Flusher.
+func (bw *bodyLimitWriter) flush() {
+	if bw.exceeded {
+		return
+	}
+	bw.ResponseWriter.(http.Flusher).Flush()
+}
+
+// hijack implements http.Hijacker.
+func (bw *bodyLimitWriter) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return bw.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// push implements http.Pusher.
+func (bw *bodyLimitWriter) push(target string, opts *http.PushOptions) error {
+	return bw.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// readFrom implements io.ReaderFrom.
+func (bw *bodyLimitWriter) readFrom(src io.Reader) (int64, error) {
+	if bw.exceeded {
+		return io.Copy(io.Discard, src)
+	}
+	return bw.ResponseWriter.(io.ReaderFrom).ReadFrom(src)
+}
+
+// bodyTooLarge returns the 413 Content Too Large problem of limit.
+func bodyTooLarge(limit int64) render.Problem {
+	return render.NewProblem(http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", limit))
+}
@@ -0,0 +1,198 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sanekee/limi"
+	"github.com/sanekee/limi/internal/testing/require"
+)
+
+func TestDecompress(t *testing.T) {
+	compress := func(encoding string, data []byte) *bytes.Buffer {
+		var buf bytes.Buffer
+		var w io.WriteCloser
+		if encoding == "deflate" {
+			w = zlib.NewWriter(&buf)
+		} else {
+			w = gzip.NewWriter(&buf)
+		}
+		w.Write(data) // nolint:errcheck
+		w.Close()
+		return &buf
+	}
+
+	var body string
+	var readErr error
+	echo := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		require.Empty(t, req.Header.Get("Content-Encoding"))
+		require.Equal(t, int64(-1), req.ContentLength)
+		b, err := io.ReadAll(req.Body)
+		body, readErr = string(b), err
+	})
+
+	t.Run("decode", func(t *testing.T) {
+		for _, encoding := range []string{"gzip", "x-gzip", "deflate"} {
+			req := httptest.NewRequest(http.MethodPost, "/", compress(encoding, []byte(`{"name":"foo"}`)))
+			req.Header.Set("Content-Encoding", encoding)
+			rec := httptest.NewRecorder()
+			Decompress(DecompressOptions{})(echo).ServeHTTP(rec, req)
+
+			require.NoError(t, readErr)
+			require.Equal(t, `{"name":"foo"}`, body)
+			require.Equal(t, encoding, req.Header.Get("Content-Encoding"))
+		}
+	})
+
+	t.Run("identity", func(t *testing.T) {
+		called := false
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("plain"))
+		Decompress(DecompressOptions{})(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			called = true
+			b, _ := io.ReadAll(req.Body)
+			require.Equal(t, "plain", string(b))
+		})).ServeHTTP(httptest.NewRecorder(), req)
+		require.True(t, called)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("data"))
+		req.Header.Set("Content-Encoding", "br")
+		rec := httptest.NewRecorder()
+		Decompress(DecompressOptions{})(echo).ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+		require.Equal(t, "gzip, deflate", rec.Header().Get("Accept-Encoding"))
+		require.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("not gzip"))
+		req.Header.Set("Content-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		Decompress(DecompressOptions{})(echo).ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("bomb", func(t *testing.T) {
+		data := compress("gzip", make([]byte, 8<<20))
+		req := httptest.NewRequest(http.MethodPost, "/", data)
+		req.Header.Set("Content-Encoding", "gzip")
+		Decompress(DecompressOptions{MaxRatio: 10})(echo).ServeHTTP(httptest.NewRecorder(), req)
+
+		var maxBytesErr *http.MaxBytesError
+		require.True(t, errors.As(readErr, &maxBytesErr))
+		require.True(t, len(body) < 8<<20)
+
+		// the bytes read are returned with the error
+		d := &decompressReader{
+			body:       io.NopCloser(bytes.NewReader(make([]byte, minRatioCheckSize+1))),
+			compressed: &countingReader{n: 1},
+			maxRatio:   1,
+		}
+		n, err := d.Read(make([]byte, minRatioCheckSize+1))
+		require.Equal(t, minRatioCheckSize+1, n)
+		require.True(t, errors.As(err, &maxBytesErr))
+	})
+}
+
+func TestBodyLimit(t *testing.T) {
+	var readErr error
+	read := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, readErr = io.ReadAll(req.Body)
+	})
+
+	t.Run("content length", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		BodyLimit(4)(read).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello")))
+
+		require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+		require.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+		require.True(t, strings.Contains(rec.Body.String(), "request body exceeds 4 bytes"))
+	})
+
+	t.Run("read", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello"))
+		req.ContentLength = -1
+		BodyLimit(4)(read).ServeHTTP(httptest.NewRecorder(), req)
+
+		var maxBytesErr *http.MaxBytesError
+		require.True(t, errors.As(readErr, &maxBytesErr))
+		require.Equal(t, int64(4), maxBytesErr.Limit)
+
+		BodyLimit(5)(read).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello")))
+		require.NoError(t, readErr)
+
+		BodyLimit(0)(read).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello")))
+		require.NoError(t, readErr)
+	})
+
+	t.Run("chunked", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello"))
+		req.ContentLength = -1
+		req.TransferEncoding = []string{"chunked"}
+		rec := httptest.NewRecorder()
+		BodyLimit(4)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, err := io.ReadAll(req.Body)
+			require.Error(t, err)
+			// the handler's response is discarded after the 413 response
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		})).ServeHTTP(rec, req)
+
+		res := rec.Result()
+		require.Equal(t, http.StatusRequestEntityTooLarge, res.StatusCode)
+		require.Equal(t, "application/problem+json", res.Header.Get("Content-Type"))
+		require.Equal(t, "close", res.Header.Get("Connection"))
+		require.True(t, strings.Contains(rec.Body.String(), "request body exceeds 4 bytes"))
+		require.False(t, strings.Contains(rec.Body.String(), "http: request body too large"))
+	})
+
+	t.Run("written response", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello"))
+		req.ContentLength = -1
+		rec := httptest.NewRecorder()
+		BodyLimit(4)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+			io.ReadAll(req.Body) // nolint:errcheck
+		})).ServeHTTP(rec, req)
+		require.Equal(t, http.StatusAccepted, rec.Code)
+	})
+
+	t.Run("route limit", func(t *testing.T) {
+		r, err := limi.NewRouter("/", limi.WithMiddlewares(BodyLimit(1)))
+		require.NoError(t, err)
+		require.NoError(t, r.With().WithMetadata(limi.Metadata{limi.MetadataBodyLimit: "1KB"}).Post("/upload", read))
+		require.NoError(t, r.Post("/small", read))
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("hello")))
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.NoError(t, readErr)
+
+		rec = httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/small", strings.NewReader("hello")))
+		require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+
+	t.Run("decompressed", func(t *testing.T) {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(bytes.Repeat([]byte("a"), 100)) // nolint:errcheck
+		zw.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/", &buf)
+		req.Header.Set("Content-Encoding", "gzip")
+		Decompress(DecompressOptions{})(BodyLimit(50)(read)).ServeHTTP(httptest.NewRecorder(), req)
+
+		var maxBytesErr *http.MaxBytesError
+		require.True(t, errors.As(readErr, &maxBytesErr))
+	})
+}
@@ -0,0 +1,359 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sanekee/limi/internal/limi"
+)
+
+const defaultCompressMinSize = 1024
+
+// defaultSkipContentTypes is the list of already compressed content types.
+var defaultSkipContentTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/webp", "image/avif",
+	"video/*", "audio/*",
+	"font/woff", "font/woff2",
+	"application/gzip", "application/x-gzip", "application/zip", "application/zstd",
+	"application/x-bzip2", "application/x-7z-compressed", "application/x-rar-compressed",
+	"application/pdf", "application/octet-stream",
+}
+
+// CompressOptions is the options of Compress.
+type CompressOptions struct {
+	// Level is the compression level, default is gzip.DefaultCompression.
+	Level int
+	// MinSize is the minimum body size compressed, default is 1024 bytes.
+	MinSize int
+	// SkipContentTypes is the list of content types not compressed, media ranges (i.e. `video/*`) are supported.
+	// Default is the common already compressed content types, i.e. images, videos and archives.
+	SkipContentTypes []string
+}
+
+// Compress middleware compresses the response with the gzip or deflate content coding, negotiated with the Accept-Encoding header.
+//   - Responses smaller than MinSize, of the skipped content types, or with `Content-Encoding` or `Cache-Control: no-transform` are not compressed.
+//   - Responses of HEAD requests, 204 No Content, 304 Not Modified and 206 Partial Content are not compressed.
+//   - `Content-Length` set by the handler is removed when the response is compressed, and strong ETags are weakened.
+//   - Flush compresses the buffered body regardless of MinSize, for streaming responses.
+func Compress(opts CompressOptions) (func(http.Handler) http.Handler, error) {
+	if opts.Level == 0 {
+		opts.Level = gzip.DefaultCompression
+	}
+	if opts.Level < gzip.HuffmanOnly || opts.Level > gzip.BestCompression {
+		return nil, fmt.Errorf("invalid compression level %d %w", opts.Level, limi.ErrInvalidInput)
+	}
+	if opts.MinSize <= 0 {
+		opts.MinSize = defaultCompressMinSize
+	}
+	skipTypes := opts.SkipContentTypes
+	if skipTypes == nil {
+		skipTypes = defaultSkipContentTypes
+	}
+
+	c := &compressor{
+		minSize: opts.MinSize,
+		pools: map[string]*sync.Pool{
+			"gzip": {New: func() any {
+				w, _ := gzip.NewWriterLevel(io.Discard, opts.Level)
+				return w
+			}},
+			"deflate": {New: func() any {
+				// the deflate content coding is the zlib format, RFC 9110 8.4.1.2
+				w, _ := zlib.NewWriterLevel(io.Discard, opts.Level)
+				return w
+			}},
+		},
+	}
+	for _, s := range skipTypes {
+		mr, ok := limi.ParseMediaRange(s)
+		if !ok {
+			return nil, fmt.Errorf("invalid content type %q %w", s, limi.ErrInvalidInput)
+		}
+		c.skipTypes = append(c.skipTypes, mr)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			addVary(w.Header(), "Accept-Encoding")
+
+			encoding := negotiateEncoding(strings.Join(req.Header.Values("Accept-Encoding"), ","))
+			if encoding == "" || req.Method == http.MethodHead {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: WrapResponseWriter(w),
+				c:              c,
+				encoding:       encoding,
+			}
+			// the compressed stream is closed and the encoder is returned to the pool when the handler panics
+			defer cw.close() // nolint:errcheck
+			next.ServeHTTP(wrapWriter(cw, w), req)
+			cw.returned = true
+		})
+	}, nil
+}
+
+// compressor is the compiled CompressOptions.
+type compressor struct {
+	minSize   int
+	skipTypes []limi.MediaRange
+	pools     map[string]*sync.Pool
+}
+
+// encoder is the pooled gzip or zlib writer.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// compressWriter buffers the response body until MinSize is reached, then writes the compressed or identity response.
+// The embedded ResponseWriter records the response written to the underlying writer.
+type compressWriter struct {
+	ResponseWriter
+	c           *compressor
+	encoding    string
+	status      int
+	wroteHeader bool
+	started     bool
+	returned    bool // the handler has returned without panicking
+	buf         []byte
+	enc         encoder
+}
+
+// WriteHeader records the status, the header is written when the response starts.
+// Informational statuses are written immediately.
+func (cw *compressWriter) WriteHeader(statusCode int) {
+	if statusCode >= 100 && statusCode <= 199 {
+		cw.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	if cw.wroteHeader {
+		return
+	}
+	cw.status = statusCode
+	cw.wroteHeader = true
+
+	if !cw.compressible() {
+		cw.start(false) // nolint:errcheck
+	}
+}
+
+// Write buffers b until MinSize is reached.
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.enc != nil {
+		return cw.enc.Write(b)
+	}
+	if cw.started {
+		return cw.ResponseWriter.Write(b)
+	}
+
+	cw.buf = append(cw.buf, b...)
+	if len(cw.buf) < cw.c.minSize {
+		return len(b), nil
+	}
+	if err := cw.start(true); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// flush implements http.Flusher, the buffered body is compressed regardless of MinSize.
+// The response is not compressed when nothing is buffered and the Content-Type is not set, as the Content-Type is not sniffed.
+func (cw *compressWriter) flush() {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if !cw.started {
+		cw.start(len(cw.buf) > 0 || cw.Header().Get("Content-Type") != "") // nolint:errcheck
+	}
+	if cw.enc != nil {
+		cw.enc.Flush() // nolint:errcheck
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// hijack implements http.Hijacker.
+func (cw *compressWriter) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return cw.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// push implements http.Pusher.
+func (cw *compressWriter) push(target string, opts *http.PushOptions) error {
+	return cw.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// readFrom implements io.ReaderFrom, src is copied through the compressor.
+func (cw *compressWriter) readFrom(src io.Reader) (int64, error) {
+	return io.Copy(writerOnly{cw}, src)
+}
+
+// writerOnly hides the io.ReaderFrom of the writer from io.Copy.
+type writerOnly struct {
+	io.Writer
+}
+
+// start writes the header and the buffered body, compressed when compress is true and the response is compressible.
+func (cw *compressWriter) start(compress bool) error {
+	cw.started = true
+	h := cw.Header()
+
+	if compress && h.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		// the server sniffs the compressed body otherwise
+		h.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+	if compress && cw.compressible() && !cw.skipContentType() {
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+		cw.enc = cw.c.pools[cw.encoding].Get().(encoder)
+		cw.enc.Reset(cw.ResponseWriter)
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if cw.enc != nil {
+		_, err := cw.enc.Write(buf)
+		return err
+	}
+	_, err := cw.ResponseWriter.Write(buf)
+	return err
+}
+
+// close writes the buffered body without compression, or closes the compressed stream.
+// The buffered body is discarded when the handler panics, leaving the response to the panic handler.
+func (cw *compressWriter) close() error {
+	if !cw.started {
+		if !cw.wroteHeader || !cw.returned {
+			return nil
+		}
+		return cw.start(false)
+	}
+	if cw.enc == nil {
+		return nil
+	}
+
+	err := cw.enc.Close()
+	cw.enc.Reset(io.Discard)
+	cw.c.pools[cw.encoding].Put(cw.enc)
+	cw.enc = nil
+	return err
+}
+
+// compressible returns true when the status and header allow compression.
+func (cw *compressWriter) compressible() bool {
+	switch {
+	case cw.status < 200,
+		cw.status == http.StatusNoContent,
+		cw.status == http.StatusNotModified,
+		cw.status == http.StatusPartialContent:
+		return false
+	}
+
+	h := cw.Header()
+	if h.Get("Content-Encoding") != "" || h.Get("Content-Range") != "" {
+		return false
+	}
+	for _, v := range h.Values("Cache-Control") {
+		if strings.Contains(strings.ToLower(v), "no-transform") {
+			return false
+		}
+	}
+	if cl := h.Get("Content-Length"); cl != "" {
+		n, err := strconv.Atoi(cl)
+		if err != nil || n < cw.c.minSize {
+			return false
+		}
+	}
+	return true
+}
+
+// skipContentType returns true when the content type is one of the skipped content types.
+func (cw *compressWriter) skipContentType() bool {
+	ct, ok := limi.ParseMediaRange(cw.Header().Get("Content-Type"))
+	if !ok {
+		return false
+	}
+	for _, mr := range cw.c.skipTypes {
+		if mr.Match(ct) {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateEncoding returns the supported content coding with the highest quality in the Accept-Encoding header,
+// gzip is preferred over deflate with the same quality. Empty when no supported content coding is acceptable.
+func negotiateEncoding(acceptEncoding string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	qualities := map[string]float64{}
+	wildcard := -1.0
+	for _, s := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(s, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, p := range strings.Split(params, ";") {
+			k, v, ok := strings.Cut(p, "=")
+			if !ok || !strings.EqualFold(strings.TrimSpace(k), "q") {
+				continue
+			}
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil || f < 0 || f > 1 {
+				f = 0
+			}
+			q = f
+		}
+
+		if coding == "*" {
+			wildcard = q
+			continue
+		}
+		if coding == "x-gzip" {
+			coding = "gzip"
+		}
+		qualities[coding] = q
+	}
+
+	var selected string
+	var selectedQ float64
+	for _, coding := range []string{"gzip", "deflate"} {
+		q, ok := qualities[coding]
+		if !ok {
+			q = wildcard
+		}
+		if q > selectedQ {
+			selected = coding
+			selectedQ = q
+		}
+	}
+	return selected
+}
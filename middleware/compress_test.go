@@ -0,0 +1,256 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sanekee/limi/internal/testing/require"
+)
+
+func TestCompress(t *testing.T) {
+	body := strings.Repeat("hello world ", 200)
+
+	serve := func(t *testing.T, opts CompressOptions, req *http.Request, handler http.HandlerFunc) *httptest.ResponseRecorder {
+		mw, err := Compress(opts)
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		mw(handler).ServeHTTP(rec, req)
+		return rec
+	}
+	newRequest := func(method, acceptEncoding string) *http.Request {
+		req := httptest.NewRequest(method, "/", nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		return req
+	}
+	write := func(contentType, body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			if contentType != "" {
+				w.Header().Set("Content-Type", contentType)
+			}
+			w.Write([]byte(body)) // nolint:errcheck
+		}
+	}
+
+	t.Run("gzip", func(t *testing.T) {
+		rec := serve(t, CompressOptions{}, newRequest(http.MethodGet, "gzip, deflate"), func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Length", "2400")
+			w.Header().Set("ETag", `"v1"`)
+			for i := 0; i < 200; i++ {
+				w.Write([]byte("hello world ")) // nolint:errcheck
+			}
+		})
+
+		require.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+		require.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+		require.Empty(t, rec.Header().Get("Content-Length"))
+		require.Equal(t, `W/"v1"`, rec.Header().Get("ETag"))
+		require.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+
+		zr, err := gzip.NewReader(rec.Body)
+		require.NoError(t, err)
+		b, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		require.Equal(t, body, string(b))
+	})
+
+	t.Run("deflate", func(t *testing.T) {
+		rec := serve(t, CompressOptions{}, newRequest(http.MethodGet, "gzip;q=0.5, deflate"), write("text/plain", body))
+		require.Equal(t, "deflate", rec.Header().Get("Content-Encoding"))
+
+		zr, err := zlib.NewReader(rec.Body)
+		require.NoError(t, err)
+		b, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		require.Equal(t, body, string(b))
+	})
+
+	t.Run("identity", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			req     *http.Request
+			handler http.HandlerFunc
+		}{
+			{"no accept encoding", newRequest(http.MethodGet, ""), write("text/plain", body)},
+			{"not acceptable", newRequest(http.MethodGet, "br, gzip;q=0"), write("text/plain", body)},
+			{"small body", newRequest(http.MethodGet, "gzip"), write("text/plain", "hello")},
+			{"compressed content type", newRequest(http.MethodGet, "gzip"), write("image/png", body)},
+			{"head", newRequest(http.MethodHead, "gzip"), write("text/plain", body)},
+			{"content encoding", newRequest(http.MethodGet, "gzip"), func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("Content-Encoding", "br")
+				w.Write([]byte(body)) // nolint:errcheck
+			}},
+			{"no transform", newRequest(http.MethodGet, "gzip"), func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("Cache-Control", "no-transform")
+				w.Write([]byte(body)) // nolint:errcheck
+			}},
+			{"small content length", newRequest(http.MethodGet, "gzip"), func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("Content-Length", "5")
+				w.Write([]byte("hello")) // nolint:errcheck
+			}},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				rec := serve(t, CompressOptions{}, tc.req, tc.handler)
+				require.True(t, rec.Header().Get("Content-Encoding") != "gzip")
+				require.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+				if tc.req.Method != http.MethodHead {
+					require.True(t, rec.Body.String() == body || rec.Body.String() == "hello")
+				}
+			})
+		}
+	})
+
+	t.Run("no body status", func(t *testing.T) {
+		for _, status := range []int{http.StatusNoContent, http.StatusNotModified} {
+			rec := serve(t, CompressOptions{}, newRequest(http.MethodGet, "gzip"), func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(status)
+			})
+			require.Equal(t, status, rec.Code)
+			require.Empty(t, rec.Header().Get("Content-Encoding"))
+			require.Empty(t, rec.Body.String())
+		}
+	})
+
+	t.Run("status", func(t *testing.T) {
+		rec := serve(t, CompressOptions{}, newRequest(http.MethodGet, "gzip"), func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(body)) // nolint:errcheck
+		})
+		require.Equal(t, http.StatusCreated, rec.Code)
+		require.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+
+		rec = serve(t, CompressOptions{}, newRequest(http.MethodGet, "gzip"), func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+		})
+		require.Equal(t, http.StatusAccepted, rec.Code)
+		require.Empty(t, rec.Header().Get("Content-Encoding"))
+	})
+
+	t.Run("flush", func(t *testing.T) {
+		rec := serve(t, CompressOptions{}, newRequest(http.MethodGet, "gzip"), func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write([]byte("data: 1\n\n")) // nolint:errcheck
+			w.(http.Flusher).Flush()
+			require.True(t, flushedRecorder(w).Flushed)
+			w.Write([]byte("data: 2\n\n")) // nolint:errcheck
+		})
+		require.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+
+		zr, err := gzip.NewReader(rec.Body)
+		require.NoError(t, err)
+		b, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		require.Equal(t, "data: 1\n\ndata: 2\n\n", string(b))
+	})
+
+	t.Run("flush without content type", func(t *testing.T) {
+		rec := serve(t, CompressOptions{}, newRequest(http.MethodGet, "gzip"), func(w http.ResponseWriter, req *http.Request) {
+			w.(http.Flusher).Flush()
+			w.Write([]byte(body)) // nolint:errcheck
+		})
+		require.Empty(t, rec.Header().Get("Content-Encoding"))
+		require.True(t, rec.Flushed)
+		require.Equal(t, body, rec.Body.String())
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		mw, err := Compress(CompressOptions{})
+		require.NoError(t, err)
+
+		serveRecover := func(handler http.HandlerFunc) *httptest.ResponseRecorder {
+			rec := httptest.NewRecorder()
+			func() {
+				defer func() {
+					require.Equal(t, any("panic"), recover())
+				}()
+				mw(handler).ServeHTTP(rec, newRequest(http.MethodGet, "gzip"))
+			}()
+			return rec
+		}
+
+		// the compressed stream is terminated
+		rec := serveRecover(func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte(body)) // nolint:errcheck
+			panic("panic")
+		})
+		require.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+		zr, err := gzip.NewReader(rec.Body)
+		require.NoError(t, err)
+		b, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		require.Equal(t, body, string(b))
+
+		// the buffered body is discarded
+		rec = serveRecover(func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("hello")) // nolint:errcheck
+			panic("panic")
+		})
+		require.False(t, rec.Flushed)
+		require.Empty(t, rec.Body.String())
+	})
+
+	t.Run("optional interfaces", func(t *testing.T) {
+		mw, err := Compress(CompressOptions{})
+		require.NoError(t, err)
+
+		var calls []string
+		mw(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, ok := w.(http.Flusher)
+			require.False(t, ok)
+			_, ok = w.(http.Pusher)
+			require.False(t, ok)
+
+			// read from is compressed, not passed to the underlying writer
+			n, err := w.(io.ReaderFrom).ReadFrom(strings.NewReader(strings.Repeat("a", 2048)))
+			require.NoError(t, err)
+			require.Equal(t, int64(2048), n)
+
+			_, _, err = w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+		})).ServeHTTP(newTestWriter(testHijack|testReadFrom, &calls), newRequest(http.MethodGet, "gzip"))
+		require.Equal(t, []string{"Hijack"}, calls)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		rec := serve(t, CompressOptions{MinSize: 5, SkipContentTypes: []string{"text/*"}}, newRequest(http.MethodGet, "*"), write("application/json", `{"a":1}`))
+		require.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+
+		rec = serve(t, CompressOptions{MinSize: 5, SkipContentTypes: []string{"text/*"}}, newRequest(http.MethodGet, "*"), write("text/html", "<p>hello</p>"))
+		require.Empty(t, rec.Header().Get("Content-Encoding"))
+
+		_, err := Compress(CompressOptions{Level: 10})
+		require.Error(t, err)
+		_, err = Compress(CompressOptions{SkipContentTypes: []string{"invalid"}})
+		require.Error(t, err)
+	})
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := map[string]string{
+		"":                          "",
+		"gzip":                      "gzip",
+		"x-gzip":                    "gzip",
+		"deflate, gzip":             "gzip",
+		"deflate;q=1, gzip;q=0.8":   "deflate",
+		"br":                        "",
+		"*":                         "gzip",
+		"*;q=0.5, gzip;q=0":         "deflate",
+		"identity":                  "",
+		"GZIP;Q=0.1":                "gzip",
+		"gzip;q=invalid, deflate":   "deflate",
+		"gzip;q=0.001, deflate;q=0": "gzip",
+	}
+	for accept, expected := range tests {
+		require.Equal(t, expected, negotiateEncoding(accept))
+	}
+}
+
+// flushedRecorder returns the httptest.ResponseRecorder underlying the compressed writer.
+func flushedRecorder(w http.ResponseWriter) *httptest.ResponseRecorder {
+	return w.(ResponseWriter).Unwrap().(*httptest.ResponseRecorder)
+}
@@ -0,0 +1,285 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sanekee/limi/internal/limi"
+)
+
+// defaultCORSMethods is the list of allowed methods when the route's methods are unknown.
+var defaultCORSMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+
+// CORSOptions is the options of CORS.
+type CORSOptions struct {
+	// AllowedOrigins is the list of allowed origins, `*` allows all origins.
+	// Origins are patterns with limi's label matchers, i.e. `https://{sub}.example.com` allows a single subdomain label,
+	// `http://localhost:{port:[0-9]+}` allows all ports.
+	AllowedOrigins []string
+	// AllowOriginFunc allows the origins not in AllowedOrigins.
+	AllowOriginFunc func(origin string) bool
+	// AllowedMethods is the list of methods allowed in preflight requests, in addition to the matched route's methods.
+	// When installed at router level, preflight requests are answered with the matched route's methods,
+	// limited to AllowedMethods when not empty. Default is GET, HEAD and POST when the route's methods are unknown.
+	AllowedMethods []string
+	// AllowedHeaders is the list of request headers allowed in preflight requests, the requested headers are allowed when empty.
+	AllowedHeaders []string
+	// ExposedHeaders is the list of response headers exposed to the client.
+	ExposedHeaders []string
+	// AllowCredentials allows requests with credentials, the request origin is echoed instead of `*`.
+	AllowCredentials bool
+	// MaxAge is the duration the preflight response is cached, not sent when 0.
+	MaxAge time.Duration
+	// AllowPrivateNetwork allows preflight requests from public to private networks.
+	AllowPrivateNetwork bool
+}
+
+// CORS middleware handles Cross-Origin Resource Sharing requests.
+// Preflight requests (OPTIONS with `Access-Control-Request-Method`) of allowed origins are answered with 204 No Content,
+// other requests of allowed origins are handled with the CORS response headers.
+// Requests of origins not allowed are handled without the CORS response headers.
+func CORS(opts CORSOptions) (func(http.Handler) http.Handler, error) {
+	c := cors{
+		opts:    opts,
+		methods: upperSet(opts.AllowedMethods),
+	}
+	for _, o := range opts.AllowedOrigins {
+		o = strings.TrimSpace(o)
+		if o == "*" {
+			c.allowAll = true
+			continue
+		}
+
+		pattern, err := normalizeOriginPattern(o)
+		if err != nil {
+			return nil, fmt.Errorf("invalid origin %s %w", o, err)
+		}
+		if c.origins == nil {
+			c.origins = &limi.Node{}
+		}
+		if err := c.origins.Insert(pattern, originHandle{}); err != nil {
+			return nil, fmt.Errorf("invalid origin %s %w", o, err)
+		}
+	}
+	if len(opts.AllowedHeaders) > 0 {
+		c.headers = strings.Join(opts.AllowedHeaders, ", ")
+	}
+	if len(opts.ExposedHeaders) > 0 {
+		c.exposed = strings.Join(opts.ExposedHeaders, ", ")
+	}
+	if opts.MaxAge > 0 {
+		c.maxAge = strconv.FormatInt(int64(opts.MaxAge/time.Second), 10)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			origin := req.Header.Get("Origin")
+			if !c.allowAll || opts.AllowCredentials {
+				addVary(w.Header(), "Origin")
+			}
+			if origin == "" || !c.allowOrigin(origin) {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			if req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != "" {
+				c.preflight(w, req, origin)
+				return
+			}
+
+			c.setOrigin(w.Header(), origin)
+			if c.exposed != "" {
+				w.Header().Set("Access-Control-Expose-Headers", c.exposed)
+			}
+			next.ServeHTTP(w, req)
+		})
+	}, nil
+}
+
+// cors is the compiled CORSOptions.
+type cors struct {
+	opts     CORSOptions
+	allowAll bool
+	origins  *limi.Node
+	methods  map[string]struct{}
+	headers  string
+	exposed  string
+	maxAge   string
+}
+
+// preflight answers the preflight request, the CORS headers are not set when the requested method is not allowed.
+func (c cors) preflight(w http.ResponseWriter, req *http.Request, origin string) {
+	h := w.Header()
+	addVary(h, "Access-Control-Request-Method")
+	addVary(h, "Access-Control-Request-Headers")
+	if c.opts.AllowPrivateNetwork {
+		addVary(h, "Access-Control-Request-Private-Network")
+	}
+
+	methods := c.allowedMethods(req.Context())
+	requested := strings.ToUpper(strings.TrimSpace(req.Header.Get("Access-Control-Request-Method")))
+	if !contains(methods, requested) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	c.setOrigin(h, origin)
+	h.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	if headers := c.allowedHeaders(req); headers != "" {
+		h.Set("Access-Control-Allow-Headers", headers)
+	}
+	if c.maxAge != "" {
+		h.Set("Access-Control-Max-Age", c.maxAge)
+	}
+	if c.opts.AllowPrivateNetwork && req.Header.Get("Access-Control-Request-Private-Network") == "true" {
+		h.Set("Access-Control-Allow-Private-Network", "true")
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// allowedMethods returns the sorted methods allowed by the matched route and the options.
+func (c cors) allowedMethods(ctx context.Context) []string {
+	routeMethods := limi.GetAllowedMethods(ctx)
+	if routeMethods == nil {
+		if len(c.methods) == 0 {
+			return defaultCORSMethods
+		}
+		return sortedKeys(c.methods)
+	}
+
+	methods := make(map[string]struct{}, len(routeMethods))
+	for _, m := range routeMethods {
+		if m == http.MethodOptions {
+			continue
+		}
+		if _, ok := c.methods[m]; ok || len(c.methods) == 0 {
+			methods[m] = struct{}{}
+		}
+	}
+	return sortedKeys(methods)
+}
+
+// allowedHeaders returns the allowed request headers, the requested headers when AllowedHeaders is empty.
+func (c cors) allowedHeaders(req *http.Request) string {
+	if c.headers != "" {
+		return c.headers
+	}
+	return strings.Join(req.Header.Values("Access-Control-Request-Headers"), ", ")
+}
+
+// allowOrigin returns true when the origin is allowed.
+func (c cors) allowOrigin(origin string) bool {
+	if c.allowAll {
+		return true
+	}
+	if c.origins != nil && validOrigin(origin) {
+		if h, _ := c.origins.Lookup(limi.NewContext(context.Background()), strings.ToLower(origin)); h != nil {
+			return true
+		}
+	}
+	return c.opts.AllowOriginFunc != nil && c.opts.AllowOriginFunc(origin)
+}
+
+// setOrigin sets the allowed origin headers, the origin is echoed when not all origins are allowed or with credentials.
+func (c cors) setOrigin(h http.Header, origin string) {
+	if c.allowAll && !c.opts.AllowCredentials {
+		h.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	h.Set("Access-Control-Allow-Origin", origin)
+	if c.opts.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// normalizeOriginPattern returns the origin pattern with string parts in lower case.
+func normalizeOriginPattern(pattern string) (string, error) {
+	parsers, err := limi.SplitParsers(pattern)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for _, p := range parsers {
+		if p.Type == limi.TypeString {
+			sb.WriteString(strings.ToLower(p.Str))
+			continue
+		}
+		sb.WriteString(p.Str)
+	}
+	return strings.TrimSuffix(sb.String(), "/"), nil
+}
+
+// validOrigin returns true when origin is a serialized origin, i.e. `https://example.com:8443` without path.
+func validOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && u.Scheme != "" && u.Host != "" && u.Path == "" && u.RawQuery == "" && u.Fragment == "" && u.User == nil
+}
+
+// originHandle is a node Handle to match an allowed origin.
+type originHandle struct{}
+
+// IsPartial implements Node Handle interface, returning false indicates partial match is not handled.
+func (originHandle) IsPartial() bool {
+	return false
+}
+
+// Merge implements Node Handle interface, returning true to allow duplicate origins.
+func (originHandle) Merge(limi.Handle) bool {
+	return true
+}
+
+// IsMethodAllowed implements Node Handle interface, returns true to handle all http methods.
+func (originHandle) IsMethodAllowed(string) bool {
+	return true
+}
+
+// ServeHTTP implements Node Handle interface.
+func (originHandle) ServeHTTP(http.ResponseWriter, *http.Request) {}
+
+// upperSet returns the set of the upper case values.
+func upperSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[strings.ToUpper(strings.TrimSpace(v))] = struct{}{}
+	}
+	return set
+}
+
+// sortedKeys returns the sorted keys of the set.
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// contains returns true when values contains v.
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// addVary adds value to the Vary header when it doesn't exist.
+func addVary(h http.Header, value string) {
+	for _, v := range h.Values("Vary") {
+		for _, s := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(s), value) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", value)
+}
@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sanekee/limi"
+	"github.com/sanekee/limi/internal/testing/require"
+)
+
+func TestCORS(t *testing.T) {
+	newCORS := func(t *testing.T, opts CORSOptions) http.Handler {
+		mw, err := CORS(opts)
+		require.NoError(t, err)
+		return mw(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		}))
+	}
+	newPreflight := func(origin, method string) *http.Request {
+		req := httptest.NewRequest(http.MethodOptions, "/", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", method)
+		return req
+	}
+
+	t.Run("origins", func(t *testing.T) {
+		h := newCORS(t, CORSOptions{
+			AllowedOrigins:  []string{"https://{sub}.Example.com", "http://localhost:{port:[0-9]+}", "https://app.test"},
+			AllowOriginFunc: func(origin string) bool { return origin == "https://func.test" },
+		})
+
+		tests := []struct {
+			origin  string
+			allowed bool
+		}{
+			{"https://api.example.com", true},
+			{"https://API.example.com", true},
+			{"https://a.b.example.com", false},
+			{"https://example.com", false},
+			{"https://api.example.com.evil.test", false},
+			{"http://api.example.com", false},
+			{"http://localhost:3000", true},
+			{"http://localhost:x", false},
+			{"https://app.test", true},
+			{"https://app.test/path", false},
+			{"https://func.test", true},
+			{"null", false},
+		}
+		for _, tc := range tests {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Origin", tc.origin)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusTeapot, rec.Code)
+			if tc.allowed {
+				require.Equal(t, tc.origin, rec.Header().Get("Access-Control-Allow-Origin"))
+			} else {
+				require.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+			}
+			require.Equal(t, "Origin", rec.Header().Get("Vary"))
+		}
+	})
+
+	t.Run("invalid origin", func(t *testing.T) {
+		_, err := CORS(CORSOptions{AllowedOrigins: []string{"https://{sub.example.com"}})
+		require.Error(t, err)
+	})
+
+	t.Run("all origins", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Origin", "https://any.test")
+
+		rec := httptest.NewRecorder()
+		newCORS(t, CORSOptions{AllowedOrigins: []string{"*"}, ExposedHeaders: []string{"X-Total"}}).ServeHTTP(rec, req)
+		require.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "X-Total", rec.Header().Get("Access-Control-Expose-Headers"))
+		require.Empty(t, rec.Header().Get("Vary"))
+
+		rec = httptest.NewRecorder()
+		newCORS(t, CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true}).ServeHTTP(rec, req)
+		require.Equal(t, "https://any.test", rec.Header().Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+		require.Equal(t, "Origin", rec.Header().Get("Vary"))
+	})
+
+	t.Run("preflight", func(t *testing.T) {
+		h := newCORS(t, CORSOptions{
+			AllowedOrigins:      []string{"https://app.test"},
+			AllowedHeaders:      []string{"Content-Type", "X-Api-Key"},
+			MaxAge:              10 * time.Minute,
+			AllowPrivateNetwork: true,
+		})
+
+		req := newPreflight("https://app.test", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Private-Network", "true")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusNoContent, rec.Code)
+		require.Equal(t, "https://app.test", rec.Header().Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "GET, HEAD, POST", rec.Header().Get("Access-Control-Allow-Methods"))
+		require.Equal(t, "Content-Type, X-Api-Key", rec.Header().Get("Access-Control-Allow-Headers"))
+		require.Equal(t, "600", rec.Header().Get("Access-Control-Max-Age"))
+		require.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Private-Network"))
+
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, newPreflight("https://app.test", http.MethodDelete))
+		require.Equal(t, http.StatusNoContent, rec.Code)
+		require.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, newPreflight("https://other.test", http.MethodPost))
+		require.Equal(t, http.StatusTeapot, rec.Code)
+	})
+
+	t.Run("requested headers", func(t *testing.T) {
+		req := newPreflight("https://app.test", http.MethodGet)
+		req.Header.Set("Access-Control-Request-Headers", "content-type,x-trace")
+		rec := httptest.NewRecorder()
+		newCORS(t, CORSOptions{AllowedOrigins: []string{"https://app.test"}}).ServeHTTP(rec, req)
+
+		require.Equal(t, "content-type,x-trace", rec.Header().Get("Access-Control-Allow-Headers"))
+		require.Empty(t, rec.Header().Get("Access-Control-Max-Age"))
+	})
+
+	t.Run("route methods", func(t *testing.T) {
+		mw, err := CORS(CORSOptions{AllowedOrigins: []string{"https://app.test"}})
+		require.NoError(t, err)
+
+		r, err := limi.NewRouter("/", limi.WithMiddlewares(mw))
+		require.NoError(t, err)
+		noop := func(w http.ResponseWriter, req *http.Request) {}
+		require.NoError(t, r.Get("/items/{id}", noop))
+		require.NoError(t, r.Put("/items/{id}", noop))
+		require.NoError(t, r.Delete("/items/{id}", noop))
+
+		rec := httptest.NewRecorder()
+		req := newPreflight("https://app.test", http.MethodDelete)
+		req.URL.Path = "/items/1"
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusNoContent, rec.Code)
+		require.Equal(t, "DELETE, GET, PUT", rec.Header().Get("Access-Control-Allow-Methods"))
+
+		rec = httptest.NewRecorder()
+		req = newPreflight("https://app.test", http.MethodPost)
+		req.URL.Path = "/items/1"
+		r.ServeHTTP(rec, req)
+		require.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+
+		// limited to the allowed methods
+		mw, err = CORS(CORSOptions{AllowedOrigins: []string{"https://app.test"}, AllowedMethods: []string{"get"}})
+		require.NoError(t, err)
+		r, err = limi.NewRouter("/", limi.WithMiddlewares(mw))
+		require.NoError(t, err)
+		require.NoError(t, r.Get("/items/{id}", noop))
+		require.NoError(t, r.Put("/items/{id}", noop))
+
+		rec = httptest.NewRecorder()
+		req = newPreflight("https://app.test", http.MethodGet)
+		req.URL.Path = "/items/1"
+		r.ServeHTTP(rec, req)
+		require.Equal(t, "GET", rec.Header().Get("Access-Control-Allow-Methods"))
+	})
+}
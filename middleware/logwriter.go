@@ -0,0 +1,354 @@
+package middleware
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultAsyncBufferSize    = 64 * 1024
+	defaultAsyncFlushInterval = time.Second
+	defaultAsyncQueueSize     = 1024
+)
+
+// ErrWriterClosed is returned when writing to a closed writer.
+var ErrWriterClosed = errors.New("writer closed")
+
+// AsyncWriterOptions is the options of NewAsyncWriter.
+type AsyncWriterOptions struct {
+	// BufferSize is the size of the write buffer, default is 64KiB.
+	BufferSize int
+	// FlushInterval is the interval flushing the buffer, default is 1s.
+	FlushInterval time.Duration
+	// QueueSize is the number of pending writes before Write blocks, default is 1024.
+	QueueSize int
+}
+
+// AsyncWriter is a buffered writer writing to the underlying writer in a background goroutine.
+// The buffer is flushed when it's full, on every flush interval and on Close.
+type AsyncWriter struct {
+	mu     sync.RWMutex
+	closed bool
+	queue  chan []byte
+	flush  chan chan error
+	done   chan error
+	w      io.Writer
+}
+
+// NewAsyncWriter returns an AsyncWriter writing to w, Close must be called to flush the pending writes.
+func NewAsyncWriter(w io.Writer, opts AsyncWriterOptions) *AsyncWriter {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = defaultAsyncBufferSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultAsyncFlushInterval
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultAsyncQueueSize
+	}
+
+	a := &AsyncWriter{
+		queue: make(chan []byte, opts.QueueSize),
+		flush: make(chan chan error),
+		done:  make(chan error, 1),
+		w:     w,
+	}
+	go a.run(bufio.NewWriterSize(w, opts.BufferSize), opts.FlushInterval)
+	return a
+}
+
+// Write queues a copy of p to be written, it never returns the underlying writer's errors.
+func (a *AsyncWriter) Write(p []byte) (int, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return 0, ErrWriterClosed
+	}
+
+	a.queue <- append([]byte(nil), p...)
+	return len(p), nil
+}
+
+// Flush writes the queued and buffered data to the underlying writer.
+func (a *AsyncWriter) Flush() error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return ErrWriterClosed
+	}
+
+	ch := make(chan error)
+	a.flush <- ch
+	return <-ch
+}
+
+// Close flushes the pending writes and stops the background goroutine,
+// the underlying writer is closed when it's an io.Closer.
+func (a *AsyncWriter) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return ErrWriterClosed
+	}
+	a.closed = true
+	close(a.queue)
+	a.mu.Unlock()
+
+	err := <-a.done
+	if c, ok := a.w.(io.Closer); ok {
+		if cErr := c.Close(); err == nil {
+			err = cErr
+		}
+	}
+	return err
+}
+
+// run writes the queued data to bw until the queue is closed.
+func (a *AsyncWriter) run(bw *bufio.Writer, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var err error
+	for {
+		select {
+		case p, ok := <-a.queue:
+			if !ok {
+				if fErr := bw.Flush(); err == nil {
+					err = fErr
+				}
+				a.done <- err
+				return
+			}
+			if _, wErr := bw.Write(p); wErr != nil && err == nil {
+				err = wErr
+			}
+		case ch := <-a.flush:
+			a.drain(bw)
+			ch <- bw.Flush()
+		case <-ticker.C:
+			if bw.Buffered() > 0 {
+				bw.Flush() // nolint:errcheck
+			}
+		}
+	}
+}
+
+// drain writes the queued data to bw without blocking.
+func (a *AsyncWriter) drain(bw *bufio.Writer) {
+	for {
+		select {
+		case p := <-a.queue:
+			bw.Write(p) // nolint:errcheck
+		default:
+			return
+		}
+	}
+}
+
+const rotatedTimeFormat = "20060102T150405.000"
+
+// RotateOptions is the options of NewRotatingFile.
+type RotateOptions struct {
+	// MaxSize is the size in bytes rotating the file, the file is not rotated by size when MaxSize is 0.
+	MaxSize int64
+	// Interval is the age rotating the file, the file is not rotated by time when Interval is 0.
+	Interval time.Duration
+	// MaxBackups is the number of rotated files kept, all rotated files are kept when MaxBackups is 0.
+	MaxBackups int
+}
+
+// RotatingFile is a file writer rotating the file by size or time.
+// Rotated files are renamed with the rotation time, e.g. `access-20240102T150405.000.log`.
+type RotatingFile struct {
+	mu       sync.Mutex
+	path     string
+	opts     RotateOptions
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	closed   bool
+}
+
+// NewRotatingFile returns a RotatingFile appending to the file at path.
+func NewRotatingFile(path string, opts RotateOptions) (*RotatingFile, error) {
+	if path == "" {
+		return nil, fmt.Errorf("missing file path")
+	}
+	if opts.MaxSize < 0 || opts.Interval < 0 || opts.MaxBackups < 0 {
+		return nil, fmt.Errorf("invalid rotate options")
+	}
+
+	f := &RotatingFile{
+		path: path,
+		opts: opts,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write writes p to the file, the file is rotated before the write when it exceeds the size or age.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, ErrWriterClosed
+	}
+	// the file failed to reopen in the last rotation
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if f.shouldRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return ErrWriterClosed
+	}
+	if f.file == nil {
+		return f.open()
+	}
+	return f.rotate()
+}
+
+// Close closes the file.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return ErrWriterClosed
+	}
+	f.closed = true
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// shouldRotate returns true when the file exceeds the size after writing n bytes, or exceeds the age.
+func (f *RotatingFile) shouldRotate(n int64) bool {
+	if f.opts.MaxSize > 0 && f.size > 0 && f.size+n > f.opts.MaxSize {
+		return true
+	}
+	return f.opts.Interval > 0 && time.Since(f.openedAt) >= f.opts.Interval
+}
+
+// open opens the file for appending.
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return fmt.Errorf("error creating log directory %w", err)
+	}
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("error opening log file %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("error opening log file %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = time.Now()
+	return nil
+}
+
+// rotate renames the file with the rotation time, opens a new file and removes the oldest backups.
+// The file is reopened for appending when it fails to be renamed, the next write reopens the file when it fails to be opened.
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("error closing log file %w", err)
+	}
+	f.file = nil
+
+	if err := os.Rename(f.path, f.backupName()); err != nil {
+		if openErr := f.open(); openErr != nil {
+			return fmt.Errorf("error renaming log file %w", errors.Join(err, openErr))
+		}
+		return fmt.Errorf("error renaming log file %w", err)
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	return f.removeBackups()
+}
+
+// backupName returns the name of the rotated file, the rotation time is advanced when the name exists.
+func (f *RotatingFile) backupName() string {
+	ext := filepath.Ext(f.path)
+	t := time.Now()
+	for {
+		name := strings.TrimSuffix(f.path, ext) + "-" + t.Format(rotatedTimeFormat) + ext
+		if _, err := os.Stat(name); errors.Is(err, os.ErrNotExist) {
+			return name
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+// removeBackups removes the oldest rotated files exceeding MaxBackups.
+func (f *RotatingFile) removeBackups() error {
+	if f.opts.MaxBackups == 0 {
+		return nil
+	}
+
+	ext := filepath.Ext(f.path)
+	prefix := filepath.Base(strings.TrimSuffix(f.path, ext)) + "-"
+	entries, err := os.ReadDir(filepath.Dir(f.path))
+	if err != nil {
+		return fmt.Errorf("error reading log directory %w", err)
+	}
+
+	var backups []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		if _, err := time.Parse(rotatedTimeFormat, strings.TrimSuffix(name[len(prefix):], ext)); err != nil {
+			continue
+		}
+		backups = append(backups, name)
+	}
+
+	// rotation time formatted names are sorted by time
+	sort.Strings(backups)
+	for len(backups) > f.opts.MaxBackups {
+		if err := os.Remove(filepath.Join(filepath.Dir(f.path), backups[0])); err != nil {
+			return fmt.Errorf("error removing log backup %w", err)
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
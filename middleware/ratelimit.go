@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sanekee/limi/internal/limi"
+	"github.com/sanekee/limi/render"
+)
+
+// Rate is a rate limit of Limit requests per Period, i.e. `middleware.Rate{Limit: 100, Period: time.Minute}`.
+type Rate = limi.Rate
+
+// RateLimitAlgorithm is the algorithm of the rate limit.
+type RateLimitAlgorithm int
+
+const (
+	// TokenBucket allows bursts of up to Burst requests, refilled at the rate.
+	TokenBucket RateLimitAlgorithm = iota
+	// SlidingWindow allows up to Limit requests in any Period, weighting the previous window's requests.
+	SlidingWindow
+)
+
+// String implements fmt.Stringer.
+func (a RateLimitAlgorithm) String() string {
+	switch a {
+	case TokenBucket:
+		return "token bucket"
+	case SlidingWindow:
+		return "sliding window"
+	}
+	return "RateLimitAlgorithm(" + strconv.Itoa(int(a)) + ")"
+}
+
+// RatePolicy is the rate limit policy applied to a key.
+type RatePolicy struct {
+	Algorithm RateLimitAlgorithm
+	Rate      Rate
+	// Burst is the token bucket capacity, Rate.Limit when 0.
+	Burst int
+}
+
+// capacity returns the number of requests allowed at once.
+func (p RatePolicy) capacity() int {
+	if p.Algorithm == TokenBucket && p.Burst > 0 {
+		return p.Burst
+	}
+	return p.Rate.Limit
+}
+
+// RateLimitResult is the result of taking a request from a key's quota.
+type RateLimitResult struct {
+	// Allowed reports whether the request is allowed.
+	Allowed bool
+	// Limit is the number of requests allowed at once.
+	Limit int
+	// Remaining is the number of remaining requests.
+	Remaining int
+	// Reset is the duration until the quota is fully restored.
+	Reset time.Duration
+	// RetryAfter is the duration until the next request is allowed, when the request is not allowed.
+	RetryAfter time.Duration
+}
+
+// Store stores the rate limit state of the keys. Take must be safe for concurrent use.
+// External stores (i.e. Redis) must apply the policy atomically, to share the limits between instances.
+type Store interface {
+	// Take takes a request from the key's quota.
+	Take(ctx context.Context, key string, policy RatePolicy) (RateLimitResult, error)
+}
+
+// KeyFunc returns the rate limit key of the request, requests with an empty key are not limited.
+type KeyFunc func(req *http.Request) string
+
+// KeyByIP returns the KeyFunc of the client IP, from the request's RemoteAddr.
+// Behind a proxy, set RemoteAddr from the trusted forwarding headers before RateLimit.
+func KeyByIP() KeyFunc {
+	return func(req *http.Request) string {
+		return remoteIP(req.RemoteAddr)
+	}
+}
+
+// KeyByHeader returns the KeyFunc of the request header value, i.e. `X-API-Key`.
+func KeyByHeader(name string) KeyFunc {
+	return func(req *http.Request) string {
+		return req.Header.Get(name)
+	}
+}
+
+// KeyByURLParam returns the KeyFunc of the matched route's URL param, i.e. `merchantId` of `/merchants/{merchantId}`.
+func KeyByURLParam(name string) KeyFunc {
+	return func(req *http.Request) string {
+		return limi.GetURLParam(req.Context(), name)
+	}
+}
+
+// RateLimitOptions is the options of RateLimit.
+type RateLimitOptions struct {
+	// Algorithm is the rate limit algorithm, default is TokenBucket.
+	Algorithm RateLimitAlgorithm
+	// Rate is the rate limit of the routes without MetadataRateLimit, the routes are not limited when empty.
+	Rate Rate
+	// Burst is the token bucket capacity of Rate, Rate.Limit when 0.
+	Burst int
+	// KeyFunc is the rate limit key of the requests, default is KeyByIP.
+	KeyFunc KeyFunc
+	// Store is the rate limit state store, default is an in-memory store.
+	Store Store
+}
+
+// RateLimit middleware limits the request rate of the keys, requests exceeding the rate are responded with 429 Too Many Requests.
+// The route's MetadataRateLimit (i.e. `limi:"ratelimit=100/1m"`) overrides Rate, limiting the keys per route.
+// Responses have the `RateLimit-Limit`, `RateLimit-Remaining`, `RateLimit-Reset` and `RateLimit-Policy` headers,
+// limited responses have the `Retry-After` header. Requests are not limited when the store fails.
+func RateLimit(opts RateLimitOptions) (func(http.Handler) http.Handler, error) {
+	if opts.Algorithm != TokenBucket && opts.Algorithm != SlidingWindow {
+		return nil, fmt.Errorf("invalid algorithm %s %w", opts.Algorithm, limi.ErrInvalidInput)
+	}
+	if opts.Rate != (Rate{}) && (opts.Rate.Limit <= 0 || opts.Rate.Period <= 0) {
+		return nil, fmt.Errorf("invalid rate %d/%s %w", opts.Rate.Limit, opts.Rate.Period, limi.ErrInvalidInput)
+	}
+	if opts.Burst < 0 {
+		return nil, fmt.Errorf("invalid burst %d %w", opts.Burst, limi.ErrInvalidInput)
+	}
+	keyFunc := opts.KeyFunc
+	if keyFunc == nil {
+		keyFunc = KeyByIP()
+	}
+	store := opts.Store
+	if store == nil {
+		store = NewMemoryStore(MemoryStoreOptions{})
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			policy := RatePolicy{Algorithm: opts.Algorithm, Rate: opts.Rate, Burst: opts.Burst}
+			key := keyFunc(req)
+			if v, ok := limi.GetRouteMetadata(req.Context(), limi.MetadataRateLimit); ok {
+				if rate, ok := limi.RateOf(v); ok {
+					policy = RatePolicy{Algorithm: opts.Algorithm, Rate: rate}
+					key = limi.GetRoutePattern(req.Context()) + "\x00" + key
+				}
+			}
+			if policy.Rate.Limit <= 0 || key == "" {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			res, err := store.Take(req.Context(), key, policy)
+			if err != nil {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", strconv.FormatInt(ceilSeconds(res.Reset), 10))
+			h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Rate.Limit, ceilSeconds(policy.Rate.Period)))
+			if !res.Allowed {
+				h.Set("Retry-After", strconv.FormatInt(ceilSeconds(res.RetryAfter), 10))
+				render.WriteProblem(w, req, render.NewProblem(http.StatusTooManyRequests, "rate limit exceeded")) // nolint:errcheck
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}, nil
+}
+
+// ceilSeconds returns d in seconds, rounded up.
+func ceilSeconds(d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	return int64(math.Ceil(d.Seconds()))
+}
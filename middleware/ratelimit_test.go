@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sanekee/limi"
+	"github.com/sanekee/limi/internal/testing/require"
+)
+
+func TestRateLimit(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newStore := func() *MemoryStore {
+		s := NewMemoryStore(MemoryStoreOptions{})
+		s.now = func() time.Time { return now }
+		return s
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {})
+	serve := func(h http.Handler, target string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("invalid options", func(t *testing.T) {
+		for _, opts := range []RateLimitOptions{
+			{Algorithm: RateLimitAlgorithm(2)},
+			{Rate: Rate{Limit: 10}},
+			{Rate: Rate{Limit: -1, Period: time.Second}},
+			{Rate: Rate{Limit: 10, Period: time.Second}, Burst: -1},
+		} {
+			_, err := RateLimit(opts)
+			require.Error(t, err)
+		}
+	})
+
+	t.Run("token bucket", func(t *testing.T) {
+		mw, err := RateLimit(RateLimitOptions{Rate: Rate{Limit: 2, Period: time.Second}, Burst: 3, Store: newStore()})
+		require.NoError(t, err)
+		h := mw(ok)
+
+		for i := 2; i >= 0; i-- {
+			rec := serve(h, "/", nil)
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.Equal(t, "3", rec.Header().Get("RateLimit-Limit"))
+			require.Equal(t, strconv.Itoa(i), rec.Header().Get("RateLimit-Remaining"))
+			require.Equal(t, "2;w=1", rec.Header().Get("RateLimit-Policy"))
+		}
+
+		rec := serve(h, "/", nil)
+		require.Equal(t, http.StatusTooManyRequests, rec.Code)
+		require.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+		require.Equal(t, "1", rec.Header().Get("Retry-After"))
+		require.Equal(t, "2", rec.Header().Get("RateLimit-Reset"))
+
+		now = now.Add(500 * time.Millisecond)
+		require.Equal(t, http.StatusOK, serve(h, "/", nil).Code)
+		require.Equal(t, http.StatusTooManyRequests, serve(h, "/", nil).Code)
+	})
+
+	t.Run("sliding window", func(t *testing.T) {
+		mw, err := RateLimit(RateLimitOptions{Algorithm: SlidingWindow, Rate: Rate{Limit: 10, Period: time.Minute}, Store: newStore()})
+		require.NoError(t, err)
+		h := mw(ok)
+
+		for i := 0; i < 10; i++ {
+			require.Equal(t, http.StatusOK, serve(h, "/", nil).Code)
+		}
+		rec := serve(h, "/", nil)
+		require.Equal(t, http.StatusTooManyRequests, rec.Code)
+		require.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+		require.Equal(t, "66", rec.Header().Get("Retry-After"))
+
+		// the previous window's requests are weighted by half
+		now = now.Add(90 * time.Second)
+		for i := 0; i < 5; i++ {
+			require.Equal(t, http.StatusOK, serve(h, "/", nil).Code)
+		}
+		rec = serve(h, "/", nil)
+		require.Equal(t, http.StatusTooManyRequests, rec.Code)
+		require.Equal(t, "6", rec.Header().Get("Retry-After"))
+
+		now = now.Add(2 * time.Minute)
+		rec = serve(h, "/", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "9", rec.Header().Get("RateLimit-Remaining"))
+	})
+
+	t.Run("keys", func(t *testing.T) {
+		mw, err := RateLimit(RateLimitOptions{Rate: Rate{Limit: 1, Period: time.Minute}, KeyFunc: KeyByHeader("X-Api-Key"), Store: newStore()})
+		require.NoError(t, err)
+		h := mw(ok)
+
+		foo := http.Header{"X-Api-Key": {"foo"}}
+		require.Equal(t, http.StatusOK, serve(h, "/", foo).Code)
+		require.Equal(t, http.StatusTooManyRequests, serve(h, "/", foo).Code)
+		require.Equal(t, http.StatusOK, serve(h, "/", http.Header{"X-Api-Key": {"bar"}}).Code)
+
+		// requests without key are not limited
+		for i := 0; i < 2; i++ {
+			rec := serve(h, "/", nil)
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.Empty(t, rec.Header().Get("RateLimit-Limit"))
+		}
+	})
+
+	t.Run("url param", func(t *testing.T) {
+		mw, err := RateLimit(RateLimitOptions{Rate: Rate{Limit: 1, Period: time.Minute}, KeyFunc: KeyByURLParam("merchantId"), Store: newStore()})
+		require.NoError(t, err)
+		r, err := limi.NewRouter("/", limi.WithMiddlewares(mw))
+		require.NoError(t, err)
+		require.NoError(t, r.Get("/merchants/{merchantId}/payments", ok))
+
+		require.Equal(t, http.StatusOK, serve(r, "/merchants/1/payments", nil).Code)
+		require.Equal(t, http.StatusTooManyRequests, serve(r, "/merchants/1/payments", nil).Code)
+		require.Equal(t, http.StatusOK, serve(r, "/merchants/2/payments", nil).Code)
+	})
+
+	t.Run("route rate", func(t *testing.T) {
+		mw, err := RateLimit(RateLimitOptions{Rate: Rate{Limit: 3, Period: time.Minute}, Store: newStore()})
+		require.NoError(t, err)
+		r, err := limi.NewRouter("/", limi.WithMiddlewares(mw))
+		require.NoError(t, err)
+		require.NoError(t, r.AddHandler(testPaymentHandler{}))
+		require.NoError(t, r.With().WithMetadata(limi.Metadata{limi.MetadataRateLimit: "2/1m"}).Get("/refunds", ok))
+		require.NoError(t, r.Get("/items", ok))
+
+		rec := serve(r, "/payments", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "1;w=60", rec.Header().Get("RateLimit-Policy"))
+		require.Equal(t, http.StatusTooManyRequests, serve(r, "/payments", nil).Code)
+
+		// routes are limited separately
+		for _, code := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+			require.Equal(t, code, serve(r, "/refunds", nil).Code)
+		}
+		for _, code := range []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+			require.Equal(t, code, serve(r, "/items", nil).Code)
+		}
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		mw, err := RateLimit(RateLimitOptions{Rate: Rate{Limit: 1, Period: time.Minute}, Store: failingStore{}})
+		require.NoError(t, err)
+		rec := serve(mw(ok), "/", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Empty(t, rec.Header().Get("RateLimit-Limit"))
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewMemoryStore(MemoryStoreOptions{Shards: 1, CleanupInterval: time.Second})
+	s.now = func() time.Time { return now }
+	policy := RatePolicy{Rate: Rate{Limit: 1, Period: time.Second}}
+
+	for _, key := range []string{"foo", "bar"} {
+		res, err := s.Take(context.Background(), key, policy)
+		require.NoError(t, err)
+		require.True(t, res.Allowed)
+	}
+	require.Len(t, s.shards[0].states, 2)
+
+	// expired keys are removed in the next cleanup
+	now = now.Add(2 * time.Second)
+	_, err := s.Take(context.Background(), "foo", policy)
+	require.NoError(t, err)
+	require.Len(t, s.shards[0].states, 1)
+}
+
+type testPaymentHandler struct {
+	_ struct{} `limi:"path=/payments,ratelimit=1/1m"`
+}
+
+func (testPaymentHandler) Get(w http.ResponseWriter, req *http.Request) {}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, RatePolicy) (RateLimitResult, error) {
+	return RateLimitResult{}, errors.New("unavailable")
+}
@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"sync"
+	"time"
+)
+
+// MemoryStoreOptions is the options of MemoryStore.
+type MemoryStoreOptions struct {
+	// Shards is the number of shards, locked separately, default is 32.
+	Shards int
+	// CleanupInterval is the interval the expired keys of a shard are removed, default is 1 minute.
+	CleanupInterval time.Duration
+}
+
+// MemoryStore is the in-memory Store of a single instance, the keys are sharded to reduce lock contention.
+// Keys expire when their quota is fully restored.
+type MemoryStore struct {
+	shards          []*rateShard
+	cleanupInterval time.Duration
+	now             func() time.Time
+}
+
+// rateShard is a shard of the MemoryStore keys.
+type rateShard struct {
+	mu          sync.Mutex
+	states      map[string]*rateState
+	nextCleanup time.Time
+}
+
+// rateState is the rate limit state of a key.
+type rateState struct {
+	// tokens and last are the token bucket tokens at last.
+	tokens float64
+	last   time.Time
+	// start, count and prev are the sliding window start, the window's requests and the previous window's requests.
+	start time.Time
+	count int
+	prev  int
+
+	expires time.Time
+}
+
+// NewMemoryStore returns a new MemoryStore.
+func NewMemoryStore(opts MemoryStoreOptions) *MemoryStore {
+	if opts.Shards <= 0 {
+		opts.Shards = 32
+	}
+	if opts.CleanupInterval <= 0 {
+		opts.CleanupInterval = time.Minute
+	}
+
+	s := &MemoryStore{
+		shards:          make([]*rateShard, opts.Shards),
+		cleanupInterval: opts.CleanupInterval,
+		now:             time.Now,
+	}
+	for i := range s.shards {
+		s.shards[i] = &rateShard{states: map[string]*rateState{}}
+	}
+	return s
+}
+
+// Take implements Store.
+func (s *MemoryStore) Take(_ context.Context, key string, policy RatePolicy) (RateLimitResult, error) {
+	h := fnv.New32a()
+	h.Write([]byte(key)) // nolint:errcheck
+	shard := s.shards[h.Sum32()%uint32(len(s.shards))]
+	now := s.now()
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if now.After(shard.nextCleanup) {
+		for k, st := range shard.states {
+			if now.After(st.expires) {
+				delete(shard.states, k)
+			}
+		}
+		shard.nextCleanup = now.Add(s.cleanupInterval)
+	}
+
+	st, ok := shard.states[key]
+	if !ok {
+		st = &rateState{tokens: float64(policy.capacity()), last: now, start: now}
+		shard.states[key] = st
+	}
+	if policy.Algorithm == SlidingWindow {
+		return st.slidingWindow(now, policy), nil
+	}
+	return st.tokenBucket(now, policy), nil
+}
+
+// tokenBucket takes a token refilled at the policy's rate.
+func (st *rateState) tokenBucket(now time.Time, policy RatePolicy) RateLimitResult {
+	capacity := float64(policy.capacity())
+	perToken := policy.Rate.Period / time.Duration(policy.Rate.Limit)
+	if elapsed := now.Sub(st.last); elapsed > 0 {
+		st.tokens = math.Min(capacity, st.tokens+float64(elapsed)/float64(perToken))
+		st.last = now
+	}
+
+	res := RateLimitResult{Limit: policy.capacity()}
+	if st.tokens >= 1 {
+		st.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - st.tokens) * float64(perToken))
+	}
+	res.Remaining = int(st.tokens)
+	res.Reset = time.Duration((capacity - st.tokens) * float64(perToken))
+	st.expires = now.Add(res.Reset)
+	return res
+}
+
+// slidingWindow counts the request in the window, weighting the previous window's requests by the window's remaining time.
+func (st *rateState) slidingWindow(now time.Time, policy RatePolicy) RateLimitResult {
+	period := policy.Rate.Period
+	limit := policy.Rate.Limit
+	if elapsed := now.Sub(st.start); elapsed >= period {
+		windows := elapsed / period
+		st.prev = 0
+		if windows == 1 {
+			st.prev = st.count
+		}
+		st.count = 0
+		st.start = st.start.Add(windows * period)
+	}
+
+	elapsed := now.Sub(st.start)
+	weight := 1 - float64(elapsed)/float64(period)
+	estimate := float64(st.prev)*weight + float64(st.count)
+
+	res := RateLimitResult{Limit: limit, Reset: period - elapsed}
+	if st.count > 0 {
+		res.Reset += period
+	}
+	if estimate+1 <= float64(limit) {
+		if st.count == 0 {
+			res.Reset += period
+		}
+		st.count++
+		estimate++
+		res.Allowed = true
+	} else if st.count < limit {
+		// the previous window's weight drops to allow a request
+		wait := float64(period)*(1-float64(limit-1-st.count)/float64(st.prev)) - float64(elapsed)
+		res.RetryAfter = time.Duration(math.Max(wait, 0))
+	} else {
+		// the window's requests are weighted in the next window
+		wait := float64(period) * (1 - float64(limit-1)/float64(st.count))
+		res.RetryAfter = period - elapsed + time.Duration(wait)
+	}
+	res.Remaining = int(math.Max(float64(limit)-math.Ceil(estimate), 0))
+	st.expires = st.start.Add(2 * period)
+	return res
+}
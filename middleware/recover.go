@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/sanekee/limi/internal/limi"
+)
+
+// PanicSink reports the panics recovered by Recover.
+type PanicSink interface {
+	ReportPanic(req *http.Request, v any, stack []byte)
+}
+
+// PanicSinkFunc is a function implementing PanicSink.
+type PanicSinkFunc func(req *http.Request, v any, stack []byte)
+
+// ReportPanic implements PanicSink.
+func (f PanicSinkFunc) ReportPanic(req *http.Request, v any, stack []byte) {
+	f(req, v, stack)
+}
+
+// RecoverOptions is the options of Recover.
+type RecoverOptions struct {
+	// Sink reports the recovered panic with the stack trace, default logs with the standard logger.
+	Sink PanicSink
+	// Handler responds to the request recovered from panic,
+	// default is the router's internal error handler (i.e. limi.WithInternalErrorHandler), otherwise 500 Internal Server Error.
+	Handler http.Handler
+}
+
+// Recover middleware recovers the panics of the handlers, reports the panic with the stack trace to the sink,
+// and responds with the internal error handler.
+// Panics with http.ErrAbortHandler are not recovered.
+// The connection is aborted when the response has already started, as the status can't be changed.
+func Recover(opts RecoverOptions) func(http.Handler) http.Handler {
+	sink := opts.Sink
+	if sink == nil {
+		sink = PanicSinkFunc(logPanic)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			rw := WrapResponseWriter(w)
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if err, ok := v.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(v)
+				}
+
+				sink.ReportPanic(req, v, debug.Stack())
+
+				if rw.Status() != 0 {
+					panic(http.ErrAbortHandler)
+				}
+				internalErrorHandler(req, opts.Handler).ServeHTTP(rw, req)
+			}()
+
+			next.ServeHTTP(rw, req)
+		})
+	}
+}
+
+// internalErrorHandler returns h, or the router's internal error handler, or the default 500 Internal Server Error handler.
+func internalErrorHandler(req *http.Request, h http.Handler) http.Handler {
+	if h != nil {
+		return h
+	}
+	if h := limi.GetInternalErrorHandler(req.Context()); h != nil {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	})
+}
+
+// logPanic logs the panic with the standard logger.
+func logPanic(req *http.Request, v any, stack []byte) {
+	log.Printf("panic serving %s %s: %v\n%s", req.Method, req.URL.Path, v, stack)
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sanekee/limi"
+	"github.com/sanekee/limi/internal/testing/require"
+)
+
+func TestRecover(t *testing.T) {
+	panicHandler := func(v any) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			panic(v)
+		}
+	}
+
+	t.Run("recover", func(t *testing.T) {
+		var reported any
+		var stack []byte
+		sink := PanicSinkFunc(func(req *http.Request, v any, s []byte) {
+			reported = v
+			stack = s
+		})
+
+		rec := httptest.NewRecorder()
+		Recover(RecoverOptions{Sink: sink})(panicHandler("boom")).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		require.Equal(t, http.StatusInternalServerError, rec.Code)
+		require.Equal(t, "boom", reported)
+		require.True(t, strings.Contains(string(stack), "TestRecover"))
+	})
+
+	t.Run("custom handler", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})
+		Recover(RecoverOptions{Sink: PanicSinkFunc(func(*http.Request, any, []byte) {}), Handler: handler})(panicHandler(errors.New("boom"))).
+			ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	})
+
+	t.Run("router internal error handler", func(t *testing.T) {
+		var reported bool
+		r, err := limi.NewRouter("/",
+			limi.WithMiddlewares(Recover(RecoverOptions{Sink: PanicSinkFunc(func(*http.Request, any, []byte) { reported = true })})),
+			limi.WithInternalErrorHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+			})),
+		)
+		require.NoError(t, err)
+
+		sr, err := r.AddRouter("/sub")
+		require.NoError(t, err)
+		require.NoError(t, sr.Get("/panic", panicHandler("boom")))
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sub/panic", nil))
+		require.Equal(t, http.StatusTeapot, rec.Code)
+		require.True(t, reported)
+	})
+
+	t.Run("abort handler", func(t *testing.T) {
+		var reported bool
+		sink := PanicSinkFunc(func(*http.Request, any, []byte) { reported = true })
+
+		defer func() {
+			require.Equal(t, http.ErrAbortHandler, recover())
+			require.False(t, reported)
+		}()
+		Recover(RecoverOptions{Sink: sink})(panicHandler(http.ErrAbortHandler)).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+
+	t.Run("response started", func(t *testing.T) {
+		var reported bool
+		sink := PanicSinkFunc(func(*http.Request, any, []byte) { reported = true })
+
+		rec := httptest.NewRecorder()
+		defer func() {
+			require.Equal(t, http.ErrAbortHandler, recover())
+			require.True(t, reported)
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.Equal(t, "partial", rec.Body.String())
+		}()
+		Recover(RecoverOptions{Sink: sink})(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("partial")) // nolint:errcheck
+			panic("boom")
+		})).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/sanekee/limi/internal/limi"
+)
+
+const (
+	defaultRequestIDHeader = "X-Request-Id"
+	maxRequestIDLength     = 128
+)
+
+// RequestIDOptions is the options of RequestID.
+type RequestIDOptions struct {
+	// Header is the request and response header of the request ID, default is `X-Request-Id`.
+	Header string
+	// Generator generates the request ID when the request has no valid request ID, default is NewULID.
+	Generator func() string
+}
+
+// RequestID middleware sets the request ID in the context, from the request header or generated when the header is missing or invalid.
+// The request ID is echoed in the response header, logged by Log and SlogLog, added to the render.WriteProblem responses,
+// and propagated to outbound requests with RequestIDTransport.
+// Valid request IDs have up to 128 visible ASCII characters.
+func RequestID(opts RequestIDOptions) func(http.Handler) http.Handler {
+	header := opts.Header
+	if header == "" {
+		header = defaultRequestIDHeader
+	}
+	generate := opts.Generator
+	if generate == nil {
+		generate = NewULID
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			id := req.Header.Get(header)
+			if !validRequestID(id) {
+				id = generate()
+			}
+
+			w.Header().Set(header, id)
+			next.ServeHTTP(w, req.WithContext(limi.WithRequestID(req.Context(), id)))
+		})
+	}
+}
+
+// RequestIDFromContext returns the request ID set by RequestID, empty when not set.
+func RequestIDFromContext(ctx context.Context) string {
+	return limi.GetRequestID(ctx)
+}
+
+// RequestIDTransport is an http.RoundTripper setting the request ID of the request context in the outbound request header.
+type RequestIDTransport struct {
+	// Base is the underlying http.RoundTripper, default is http.DefaultTransport.
+	Base http.RoundTripper
+	// Header is the request header of the request ID, default is `X-Request-Id`.
+	Header string
+}
+
+// RoundTrip implements http.RoundTripper, the request header is not overwritten when set.
+func (t *RequestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	header := t.Header
+	if header == "" {
+		header = defaultRequestIDHeader
+	}
+
+	id := limi.GetRequestID(req.Context())
+	if id == "" || req.Header.Get(header) != "" {
+		return base.RoundTrip(req)
+	}
+
+	// RoundTrippers must not modify the request
+	req = req.Clone(req.Context())
+	req.Header.Set(header, id)
+	return base.RoundTrip(req)
+}
+
+// crockford is the Crockford's base32 alphabet of ULID.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID returns a ULID, a 48 bits millisecond timestamp and 80 bits of randomness encoded in 26 characters.
+func NewULID() string {
+	var b [16]byte
+	putTimestamp(b[:], time.Now())
+	rand.Read(b[6:]) // nolint:errcheck
+
+	// 128 bits are encoded as 26 base32 characters, the first character has the 3 most significant bits
+	hi := binary.BigEndian.Uint64(b[:8])
+	lo := binary.BigEndian.Uint64(b[8:])
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// NewUUIDv7 returns an RFC 9562 UUID version 7, a 48 bits millisecond timestamp and 74 bits of randomness.
+func NewUUIDv7() string {
+	var b [16]byte
+	putTimestamp(b[:], time.Now())
+	rand.Read(b[6:])        // nolint:errcheck
+	b[6] = b[6]&0x0f | 0x70 // version 7
+	b[8] = b[8]&0x3f | 0x80 // variant 10
+
+	var out [36]byte
+	hex.Encode(out[0:8], b[0:4])
+	out[8] = '-'
+	hex.Encode(out[9:13], b[4:6])
+	out[13] = '-'
+	hex.Encode(out[14:18], b[6:8])
+	out[18] = '-'
+	hex.Encode(out[19:23], b[8:10])
+	out[23] = '-'
+	hex.Encode(out[24:], b[10:])
+	return string(out[:])
+}
+
+// putTimestamp puts the 48 bits unix millisecond timestamp of t in b.
+func putTimestamp(b []byte, t time.Time) {
+	ms := uint64(t.UnixMilli())
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+}
+
+// validRequestID returns true when id has 1 to 128 visible ASCII characters.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
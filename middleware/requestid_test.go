@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/sanekee/limi/internal/testing/require"
+	"github.com/sanekee/limi/render"
+)
+
+func TestRequestID(t *testing.T) {
+	var got string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = RequestIDFromContext(req.Context())
+	})
+
+	t.Run("incoming", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Request-Id", "abc")
+		rec := httptest.NewRecorder()
+		RequestID(RequestIDOptions{})(handler).ServeHTTP(rec, req)
+
+		require.Equal(t, "abc", got)
+		require.Equal(t, "abc", rec.Header().Get("X-Request-Id"))
+	})
+
+	t.Run("generate", func(t *testing.T) {
+		for _, id := range []string{"", "a b", strings.Repeat("a", 129)} {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("X-Correlation-Id", id)
+			rec := httptest.NewRecorder()
+			RequestID(RequestIDOptions{
+				Header:    "X-Correlation-Id",
+				Generator: func() string { return "generated" },
+			})(handler).ServeHTTP(rec, req)
+
+			require.Equal(t, "generated", got)
+			require.Equal(t, "generated", rec.Header().Get("X-Correlation-Id"))
+		}
+	})
+
+	t.Run("log", func(t *testing.T) {
+		var buf bytes.Buffer
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Request-Id", "abc")
+		RequestID(RequestIDOptions{})(Log(log.New(&buf, "", 0))(handler)).ServeHTTP(httptest.NewRecorder(), req)
+
+		require.True(t, strings.HasSuffix(buf.String(), " abc\n"))
+	})
+
+	t.Run("problem", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Request-Id", "abc")
+		rec := httptest.NewRecorder()
+		RequestID(RequestIDOptions{})(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			render.WriteProblem(w, req, render.NewProblem(http.StatusBadRequest, "")) // nolint:errcheck
+		})).ServeHTTP(rec, req)
+
+		require.True(t, strings.Contains(rec.Body.String(), `"requestId":"abc"`))
+	})
+}
+
+func TestRequestIDTransport(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = req.Header.Get("X-Request-Id")
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &RequestIDTransport{}}
+	RequestID(RequestIDOptions{})(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		outReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, srv.URL, nil)
+		require.NoError(t, err)
+
+		resp, err := client.Do(outReq)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Empty(t, outReq.Header.Get("X-Request-Id"))
+	})).ServeHTTP(httptest.NewRecorder(), func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Request-Id", "abc")
+		return req
+	}())
+
+	require.Equal(t, "abc", got)
+}
+
+func TestNewULID(t *testing.T) {
+	re := regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+	id1, id2 := NewULID(), NewULID()
+	require.True(t, re.MatchString(id1))
+	require.True(t, id1 != id2)
+	// the timestamp prefix is sortable
+	require.True(t, id1[:10] <= id2[:10])
+}
+
+func TestNewUUIDv7(t *testing.T) {
+	re := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	id1, id2 := NewUUIDv7(), NewUUIDv7()
+	require.True(t, re.MatchString(id1))
+	require.True(t, id1 != id2)
+}
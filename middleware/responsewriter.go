@@ -0,0 +1,293 @@
+package middleware
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ResponseWriter is an http.ResponseWriter recording the response.
+type ResponseWriter interface {
+	http.ResponseWriter
+	// Status returns the response status, 0 when the response has not started.
+	// Informational statuses are not recorded, a hijacked connection has status 101 Switching Protocols.
+	Status() int
+	// BytesWritten returns the number of body bytes written.
+	BytesWritten() int64
+	// FirstByteTime returns the time the response started, zero when the response has not started.
+	FirstByteTime() time.Time
+	// WrittenHeader returns the response header at the time the response started, nil when the response has not started.
+	WrittenHeader() http.Header
+	// Unwrap returns the underlying http.ResponseWriter, used by http.ResponseController.
+	Unwrap() http.ResponseWriter
+}
+
+// WrapResponseWriter returns a ResponseWriter recording the response written to w.
+// The returned writer implements the same optional interfaces as w, among http.Flusher, http.Hijacker, http.Pusher and io.ReaderFrom.
+// w is returned when it's already a ResponseWriter.
+func WrapResponseWriter(w http.ResponseWriter) ResponseWriter {
+	if rw, ok := w.(ResponseWriter); ok {
+		return rw
+	}
+	return wrapWriter(&recorder{ResponseWriter: w}, w)
+}
+
+// writerCore is a ResponseWriter implementing the optional interfaces with unexported methods,
+// exposed by wrapWriter when the underlying writer implements them.
+type writerCore interface {
+	ResponseWriter
+	flush()
+	hijack() (net.Conn, *bufio.ReadWriter, error)
+	push(target string, opts *http.PushOptions) error
+	readFrom(src io.Reader) (int64, error)
+}
+
+// wrapWriter returns c implementing the same optional interfaces as w, among http.Flusher, http.Hijacker, http.Pusher and io.ReaderFrom.
+func wrapWriter(c writerCore, w http.ResponseWriter) ResponseWriter {
+	const (
+		flush = 1 << iota
+		hijack
+		push
+		readFrom
+	)
+	var flags int
+	if _, ok := w.(http.Flusher); ok {
+		flags |= flush
+	}
+	if _, ok := w.(http.Hijacker); ok {
+		flags |= hijack
+	}
+	if _, ok := w.(http.Pusher); ok {
+		flags |= push
+	}
+	if _, ok := w.(io.ReaderFrom); ok {
+		flags |= readFrom
+	}
+
+	f, h, p, rf := flusher{c}, hijacker{c}, pusher{c}, readerFrom{c}
+	switch flags {
+	case flush:
+		return struct {
+			writerCore
+			flusher
+		}{c, f}
+	case hijack:
+		return struct {
+			writerCore
+			hijacker
+		}{c, h}
+	case flush | hijack:
+		return struct {
+			writerCore
+			flusher
+			hijacker
+		}{c, f, h}
+	case push:
+		return struct {
+			writerCore
+			pusher
+		}{c, p}
+	case flush | push:
+		return struct {
+			writerCore
+			flusher
+			pusher
+		}{c, f, p}
+	case hijack | push:
+		return struct {
+			writerCore
+			hijacker
+			pusher
+		}{c, h, p}
+	case flush | hijack | push:
+		return struct {
+			writerCore
+			flusher
+			hijacker
+			pusher
+		}{c, f, h, p}
+	case readFrom:
+		return struct {
+			writerCore
+			readerFrom
+		}{c, rf}
+	case flush | readFrom:
+		return struct {
+			writerCore
+			flusher
+			readerFrom
+		}{c, f, rf}
+	case hijack | readFrom:
+		return struct {
+			writerCore
+			hijacker
+			readerFrom
+		}{c, h, rf}
+	case flush | hijack | readFrom:
+		return struct {
+			writerCore
+			flusher
+			hijacker
+			readerFrom
+		}{c, f, h, rf}
+	case push | readFrom:
+		return struct {
+			writerCore
+			pusher
+			readerFrom
+		}{c, p, rf}
+	case flush | push | readFrom:
+		return struct {
+			writerCore
+			flusher
+			pusher
+			readerFrom
+		}{c, f, p, rf}
+	case hijack | push | readFrom:
+		return struct {
+			writerCore
+			hijacker
+			pusher
+			readerFrom
+		}{c, h, p, rf}
+	case flush | hijack | push | readFrom:
+		return struct {
+			writerCore
+			flusher
+			hijacker
+			pusher
+			readerFrom
+		}{c, f, h, p, rf}
+	}
+	return c
+}
+
+// recorder is the response recorder of WrapResponseWriter.
+type recorder struct {
+	http.ResponseWriter
+	status    int
+	bytes     int64
+	firstByte time.Time
+	header    http.Header
+}
+
+// WriteHeader records the response status, informational statuses are not recorded.
+func (r *recorder) WriteHeader(statusCode int) {
+	if statusCode < 100 || statusCode > 199 {
+		r.start(statusCode)
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write records the body length, the status is 200 OK when the header is not written.
+func (r *recorder) Write(b []byte) (int, error) {
+	r.start(http.StatusOK)
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Status implements ResponseWriter.
+func (r *recorder) Status() int {
+	return r.status
+}
+
+// BytesWritten implements ResponseWriter.
+func (r *recorder) BytesWritten() int64 {
+	return r.bytes
+}
+
+// FirstByteTime implements ResponseWriter.
+func (r *recorder) FirstByteTime() time.Time {
+	return r.firstByte
+}
+
+// WrittenHeader implements ResponseWriter.
+func (r *recorder) WrittenHeader() http.Header {
+	return r.header
+}
+
+// Unwrap implements ResponseWriter.
+func (r *recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// start records the response start with status, when the response has not started.
+func (r *recorder) start(status int) {
+	if r.status != 0 {
+		return
+	}
+	r.status = status
+	r.firstByte = time.Now()
+	r.header = r.ResponseWriter.Header().Clone()
+}
+
+// flush flushes the underlying writer, the status is 200 OK when the header is not written.
+func (r *recorder) flush() {
+	r.start(http.StatusOK)
+	r.ResponseWriter.(http.Flusher).Flush()
+}
+
+// hijack hijacks the underlying writer's connection, the status is 101 Switching Protocols when the connection is hijacked.
+func (r *recorder) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := r.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil {
+		r.start(http.StatusSwitchingProtocols)
+	}
+	return conn, brw, err
+}
+
+// push pushes with the underlying writer.
+func (r *recorder) push(target string, opts *http.PushOptions) error {
+	return r.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// readFrom reads from src with the underlying writer, the status is 200 OK when the header is not written.
+func (r *recorder) readFrom(src io.Reader) (int64, error) {
+	r.start(http.StatusOK)
+	n, err := r.ResponseWriter.(io.ReaderFrom).ReadFrom(src)
+	r.bytes += n
+	return n, err
+}
+
+// flusher implements http.Flusher of the writerCore.
+type flusher struct {
+	c writerCore
+}
+
+// Flush implements http.Flusher.
+func (f flusher) Flush() {
+	f.c.flush()
+}
+
+// hijacker implements http.Hijacker of the writerCore.
+type hijacker struct {
+	c writerCore
+}
+
+// Hijack implements http.Hijacker.
+func (h hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return h.c.hijack()
+}
+
+// pusher implements http.Pusher of the writerCore.
+type pusher struct {
+	c writerCore
+}
+
+// Push implements http.Pusher.
+func (p pusher) Push(target string, opts *http.PushOptions) error {
+	return p.c.push(target, opts)
+}
+
+// readerFrom implements io.ReaderFrom of the writerCore.
+type readerFrom struct {
+	c writerCore
+}
+
+// ReadFrom implements io.ReaderFrom.
+func (rf readerFrom) ReadFrom(src io.Reader) (int64, error) {
+	return rf.c.readFrom(src)
+}
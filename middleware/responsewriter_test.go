@@ -0,0 +1,264 @@
+package middleware
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sanekee/limi/internal/testing/require"
+)
+
+func TestWrapResponseWriter(t *testing.T) {
+	t.Run("record", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		rw := WrapResponseWriter(rec)
+		require.Equal(t, 0, rw.Status())
+		require.True(t, rw.FirstByteTime().IsZero())
+		require.True(t, rw.WrittenHeader() == nil)
+
+		rw.Header().Set("X-Foo", "foo")
+		rw.WriteHeader(http.StatusCreated)
+		rw.Header().Set("X-Bar", "bar")
+		_, err := rw.Write([]byte("hello"))
+		require.NoError(t, err)
+
+		require.Equal(t, http.StatusCreated, rw.Status())
+		require.Equal(t, int64(5), rw.BytesWritten())
+		require.False(t, rw.FirstByteTime().IsZero())
+		require.Equal(t, "foo", rw.WrittenHeader().Get("X-Foo"))
+		require.Equal(t, "", rw.WrittenHeader().Get("X-Bar"))
+		require.Equal(t, http.ResponseWriter(rec), rw.Unwrap())
+		require.Equal(t, rw, WrapResponseWriter(rw))
+	})
+
+	t.Run("informational status", func(t *testing.T) {
+		rw := WrapResponseWriter(httptest.NewRecorder())
+		rw.WriteHeader(http.StatusEarlyHints)
+		require.Equal(t, 0, rw.Status())
+	})
+
+	t.Run("implicit status", func(t *testing.T) {
+		rw := WrapResponseWriter(httptest.NewRecorder())
+		_, err := rw.Write([]byte("hello"))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, rw.Status())
+	})
+
+	t.Run("optional interfaces", func(t *testing.T) {
+		for flags := 0; flags < 16; flags++ {
+			rw := WrapResponseWriter(newTestWriter(flags, nil))
+			_, isFlusher := rw.(http.Flusher)
+			_, isHijacker := rw.(http.Hijacker)
+			_, isPusher := rw.(http.Pusher)
+			_, isReaderFrom := rw.(io.ReaderFrom)
+			require.Equal(t, flags&testFlush != 0, isFlusher)
+			require.Equal(t, flags&testHijack != 0, isHijacker)
+			require.Equal(t, flags&testPush != 0, isPusher)
+			require.Equal(t, flags&testReadFrom != 0, isReaderFrom)
+		}
+	})
+
+	t.Run("forward", func(t *testing.T) {
+		var calls []string
+		rw := WrapResponseWriter(newTestWriter(testFlush|testHijack|testPush|testReadFrom, &calls))
+
+		n, err := rw.(io.ReaderFrom).ReadFrom(strings.NewReader("hello"))
+		require.NoError(t, err)
+		require.Equal(t, int64(5), n)
+		require.Equal(t, int64(5), rw.BytesWritten())
+		require.Equal(t, http.StatusOK, rw.Status())
+
+		rw.(http.Flusher).Flush()
+		require.NoError(t, rw.(http.Pusher).Push("/style.css", nil))
+		_, _, err = rw.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		require.Equal(t, []string{"ReadFrom", "Flush", "Push", "Hijack"}, calls)
+	})
+
+	t.Run("hijack", func(t *testing.T) {
+		rw := WrapResponseWriter(newTestWriter(testHijack, nil))
+
+		_, _, err := rw.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		require.Equal(t, http.StatusSwitchingProtocols, rw.Status())
+	})
+
+	t.Run("response controller", func(t *testing.T) {
+		srv := httptest.NewServer(Log(testLogger{})(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			rc := http.NewResponseController(w)
+			require.NoError(t, rc.SetWriteDeadline(time.Now().Add(time.Second)))
+
+			conn, brw, err := rc.Hijack()
+			require.NoError(t, err)
+			defer conn.Close()
+			brw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 2\r\nConnection: close\r\n\r\nok") // nolint:errcheck
+			brw.Flush()                                                                            // nolint:errcheck
+		})))
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, "ok", string(b))
+	})
+}
+
+const (
+	testFlush = 1 << iota
+	testHijack
+	testPush
+	testReadFrom
+)
+
+// newTestWriter returns an http.ResponseWriter implementing only the optional interfaces of flags, recording their calls.
+func newTestWriter(flags int, calls *[]string) http.ResponseWriter {
+	if calls == nil {
+		calls = &[]string{}
+	}
+	type base struct {
+		http.ResponseWriter
+	}
+	w := testWriter{ResponseWriter: httptest.NewRecorder(), calls: calls}
+	f, h, p, rf := testFlusher{w}, testHijacker{w}, testPusher{w}, testReaderFrom{w}
+
+	switch flags {
+	case testFlush:
+		return struct {
+			base
+			http.Flusher
+		}{base{w}, f}
+	case testHijack:
+		return struct {
+			base
+			http.Hijacker
+		}{base{w}, h}
+	case testFlush | testHijack:
+		return struct {
+			base
+			http.Flusher
+			http.Hijacker
+		}{base{w}, f, h}
+	case testPush:
+		return struct {
+			base
+			http.Pusher
+		}{base{w}, p}
+	case testFlush | testPush:
+		return struct {
+			base
+			http.Flusher
+			http.Pusher
+		}{base{w}, f, p}
+	case testHijack | testPush:
+		return struct {
+			base
+			http.Hijacker
+			http.Pusher
+		}{base{w}, h, p}
+	case testFlush | testHijack | testPush:
+		return struct {
+			base
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{base{w}, f, h, p}
+	case testReadFrom:
+		return struct {
+			base
+			io.ReaderFrom
+		}{base{w}, rf}
+	case testFlush | testReadFrom:
+		return struct {
+			base
+			http.Flusher
+			io.ReaderFrom
+		}{base{w}, f, rf}
+	case testHijack | testReadFrom:
+		return struct {
+			base
+			http.Hijacker
+			io.ReaderFrom
+		}{base{w}, h, rf}
+	case testFlush | testHijack | testReadFrom:
+		return struct {
+			base
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+		}{base{w}, f, h, rf}
+	case testPush | testReadFrom:
+		return struct {
+			base
+			http.Pusher
+			io.ReaderFrom
+		}{base{w}, p, rf}
+	case testFlush | testPush | testReadFrom:
+		return struct {
+			base
+			http.Flusher
+			http.Pusher
+			io.ReaderFrom
+		}{base{w}, f, p, rf}
+	case testHijack | testPush | testReadFrom:
+		return struct {
+			base
+			http.Hijacker
+			http.Pusher
+			io.ReaderFrom
+		}{base{w}, h, p, rf}
+	case testFlush | testHijack | testPush | testReadFrom:
+		return struct {
+			base
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+			io.ReaderFrom
+		}{base{w}, f, h, p, rf}
+	}
+	return base{w}
+}
+
+// testWriter is the http.ResponseWriter of newTestWriter.
+type testWriter struct {
+	http.ResponseWriter
+	calls *[]string
+}
+
+type testFlusher struct{ w testWriter }
+
+func (f testFlusher) Flush() {
+	*f.w.calls = append(*f.w.calls, "Flush")
+}
+
+type testHijacker struct{ w testWriter }
+
+func (h testHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	*h.w.calls = append(*h.w.calls, "Hijack")
+	return nil, nil, nil
+}
+
+type testPusher struct{ w testWriter }
+
+func (p testPusher) Push(string, *http.PushOptions) error {
+	*p.w.calls = append(*p.w.calls, "Push")
+	return nil
+}
+
+type testReaderFrom struct{ w testWriter }
+
+func (rf testReaderFrom) ReadFrom(src io.Reader) (int64, error) {
+	*rf.w.calls = append(*rf.w.calls, "ReadFrom")
+	return io.Copy(rf.w.ResponseWriter, src)
+}
+
+// testLogger discards the logs.
+type testLogger struct{}
+
+func (testLogger) Println(...any) {}
@@ -0,0 +1,134 @@
+//go:build go1.21
+
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/sanekee/limi/internal/limi"
+)
+
+type slogCtxKey struct{}
+
+// SlogOptions is the options of SlogLog.
+type SlogOptions struct {
+	// Level returns the log level of the response status,
+	// default is Error for 5xx, Warn for 4xx, otherwise Info.
+	Level func(status int) slog.Level
+	// SkipPaths is the list of request paths not logged, i.e. `/healthz`.
+	SkipPaths []string
+	// SampleRate is the ratio of the successful (< 400) requests logged, i.e. 0.1 logs 1 of every 10 requests.
+	// All requests are logged when SampleRate is 0 or >= 1.
+	SampleRate float64
+	// RequestIDHeader is the request header of the request ID when it's not set by RequestID, default is `X-Request-Id`.
+	RequestIDHeader string
+}
+
+// SlogLog middleware logs the request with structured attributes with the logger:
+// method, route, path, status, bytes, latency, request_id, remote_ip and user_agent.
+// The request scoped logger with the request attributes is retrievable with LoggerFromContext.
+func SlogLog(logger *slog.Logger, opts SlogOptions) func(http.Handler) http.Handler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	level := opts.Level
+	if level == nil {
+		level = statusLevel
+	}
+	header := opts.RequestIDHeader
+	if header == "" {
+		header = defaultRequestIDHeader
+	}
+	skips := make(map[string]struct{}, len(opts.SkipPaths))
+	for _, p := range opts.SkipPaths {
+		skips[p] = struct{}{}
+	}
+	sampler := newSampler(opts.SampleRate)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if _, ok := skips[req.URL.Path]; ok {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			start := time.Now()
+			reqLogger := logger.With(
+				slog.String("method", req.Method),
+				slog.String("path", req.URL.Path),
+				slog.String("request_id", requestID(req, header)),
+			)
+			req = req.WithContext(context.WithValue(req.Context(), slogCtxKey{}, reqLogger))
+
+			rw := WrapResponseWriter(w)
+			next.ServeHTTP(rw, req)
+
+			status := rw.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status < http.StatusBadRequest && !sampler.sample() {
+				return
+			}
+
+			reqLogger.LogAttrs(req.Context(), level(status), "request",
+				slog.String("route", limi.GetRoutePattern(req.Context())),
+				slog.Int("status", status),
+				slog.Int64("bytes", rw.BytesWritten()),
+				slog.Duration("latency", time.Since(start)),
+				slog.String("remote_ip", remoteIP(req.RemoteAddr)),
+				slog.String("user_agent", req.UserAgent()),
+			)
+		})
+	}
+}
+
+// LoggerFromContext returns the request scoped logger set by SlogLog, or slog.Default when not set.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(slogCtxKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// requestID returns the request ID set by RequestID, or the request header value.
+func requestID(req *http.Request, header string) string {
+	if id := limi.GetRequestID(req.Context()); id != "" {
+		return id
+	}
+	return req.Header.Get(header)
+}
+
+// statusLevel returns the log level of the response status.
+func statusLevel(status int) slog.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return slog.LevelError
+	case status >= http.StatusBadRequest:
+		return slog.LevelWarn
+	}
+	return slog.LevelInfo
+}
+
+// sampler samples a ratio of the requests deterministically.
+type sampler struct {
+	rate  float64
+	count atomic.Uint64
+}
+
+func newSampler(rate float64) *sampler {
+	return &sampler{rate: rate}
+}
+
+// sample returns true when the request is sampled.
+func (s *sampler) sample() bool {
+	if s.rate <= 0 || s.rate >= 1 {
+		return true
+	}
+	n := s.count.Add(1)
+	return uint64(float64(n)*s.rate) != uint64(float64(n-1)*s.rate)
+}
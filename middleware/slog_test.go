@@ -0,0 +1,121 @@
+//go:build go1.21
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sanekee/limi"
+	"github.com/sanekee/limi/internal/testing/require"
+)
+
+func TestSlogLog(t *testing.T) {
+	newLogger := func(buf *bytes.Buffer) *slog.Logger {
+		return slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+
+	decodeLines := func(t *testing.T, buf *bytes.Buffer) []map[string]any {
+		var lines []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var m map[string]any
+			require.NoError(t, json.Unmarshal([]byte(line), &m))
+			lines = append(lines, m)
+		}
+		return lines
+	}
+
+	t.Run("attributes", func(t *testing.T) {
+		var buf bytes.Buffer
+		r, err := limi.NewRouter("/", limi.WithMiddlewares(SlogLog(newLogger(&buf), SlogOptions{})))
+		require.NoError(t, err)
+		require.NoError(t, r.Get("/teams/{id}", func(w http.ResponseWriter, req *http.Request) {
+			LoggerFromContext(req.Context()).Info("handling")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("team")) // nolint:errcheck
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/teams/1", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("User-Agent", "test")
+		req.Header.Set("X-Request-Id", "abc")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		lines := decodeLines(t, &buf)
+		require.Len(t, lines, 2)
+
+		require.Equal(t, "handling", lines[0]["msg"])
+		require.Equal(t, "abc", lines[0]["request_id"])
+
+		line := lines[1]
+		require.Equal(t, "INFO", line["level"])
+		require.Equal(t, "request", line["msg"])
+		require.Equal(t, http.MethodGet, line["method"])
+		require.Equal(t, "/teams/{id}", line["route"])
+		require.Equal(t, "/teams/1", line["path"])
+		require.Equal(t, float64(http.StatusCreated), line["status"])
+		require.Equal(t, float64(4), line["bytes"])
+		require.Equal(t, "abc", line["request_id"])
+		require.Equal(t, "10.0.0.1", line["remote_ip"])
+		require.Equal(t, "test", line["user_agent"])
+	})
+
+	t.Run("request id", func(t *testing.T) {
+		var buf bytes.Buffer
+		handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {})
+		RequestID(RequestIDOptions{Generator: func() string { return "generated" }})(SlogLog(newLogger(&buf), SlogOptions{})(handler)).
+			ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		lines := decodeLines(t, &buf)
+		require.Len(t, lines, 1)
+		require.Equal(t, "generated", lines[0]["request_id"])
+	})
+
+	t.Run("levels", func(t *testing.T) {
+		var buf bytes.Buffer
+		mw := SlogLog(newLogger(&buf), SlogOptions{})
+		for _, status := range []int{http.StatusOK, http.StatusNotFound, http.StatusInternalServerError} {
+			status := status
+			mw(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(status)
+			})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}
+
+		lines := decodeLines(t, &buf)
+		require.Len(t, lines, 3)
+		require.Equal(t, "INFO", lines[0]["level"])
+		require.Equal(t, "WARN", lines[1]["level"])
+		require.Equal(t, "ERROR", lines[2]["level"])
+	})
+
+	t.Run("skip paths and sampling", func(t *testing.T) {
+		var buf bytes.Buffer
+		mw := SlogLog(newLogger(&buf), SlogOptions{SkipPaths: []string{"/healthz"}, SampleRate: 0.25})
+		ok := mw(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+		fail := mw(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+
+		for i := 0; i < 8; i++ {
+			ok.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			ok.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}
+		fail.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		lines := decodeLines(t, &buf)
+		require.Len(t, lines, 3)
+		require.Equal(t, float64(http.StatusBadRequest), lines[2]["status"])
+	})
+
+	t.Run("default logger", func(t *testing.T) {
+		require.Equal(t, slog.Default(), LoggerFromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context()))
+	})
+}
@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sanekee/limi/internal/limi"
+	"github.com/sanekee/limi/render"
+)
+
+const defaultTimeoutHeader = "Request-Timeout"
+
+// TimeoutOptions is the options of Timeout.
+type TimeoutOptions struct {
+	// Status is the response status of the timed out requests, 503 Service Unavailable or 504 Gateway Timeout, default is 503.
+	Status int
+	// Header is the request header of the requested timeout, in seconds (i.e. `1.5`) or a duration (i.e. `1500ms`),
+	// default is `Request-Timeout`.
+	Header string
+	// MaxTimeout is the maximum requested timeout, the requested timeout is ignored when MaxTimeout is 0.
+	// The requested timeout shortens the route's timeout or d, it never extends them.
+	MaxTimeout time.Duration
+	// Sink reports the panics of the handlers after the timeout, default logs with the standard logger.
+	// Panics before the timeout are propagated to the caller.
+	Sink PanicSink
+}
+
+// Timeout middleware cancels the request context after d, the route's MetadataTimeout (i.e. `limi:"timeout=2s"`) overrides d.
+// When MaxTimeout is set, the requested timeout in the request header shortens the timeout, requests without timeout are timed out up to MaxTimeout.
+// The handler's response is buffered and written when the handler returns, timed out requests are responded with the Status problem,
+// and the handler's writes after the timeout return http.ErrHandlerTimeout.
+// The request is not timed out when the timeout is 0, streaming handlers (i.e. using http.Flusher) must not be timed out.
+func Timeout(d time.Duration, opts TimeoutOptions) func(http.Handler) http.Handler {
+	status := opts.Status
+	if status == 0 {
+		status = http.StatusServiceUnavailable
+	}
+	header := opts.Header
+	if header == "" {
+		header = defaultTimeoutHeader
+	}
+	sink := opts.Sink
+	if sink == nil {
+		sink = PanicSinkFunc(logPanic)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			timeout := d
+			if v, ok := limi.GetRouteMetadata(req.Context(), limi.MetadataTimeout); ok {
+				if rd, ok := limi.Duration(v); ok {
+					timeout = rd
+				}
+			}
+			if opts.MaxTimeout > 0 {
+				// the requested timeout only shortens the timeout
+				if requested, ok := parseRequestTimeout(req.Header.Get(header)); ok {
+					if requested > opts.MaxTimeout {
+						requested = opts.MaxTimeout
+					}
+					if timeout <= 0 || requested < timeout {
+						timeout = requested
+					}
+				}
+			}
+			if timeout <= 0 {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(req.Context(), timeout)
+			defer cancel()
+			req = req.WithContext(ctx)
+
+			tw := &timeoutWriter{header: make(http.Header)}
+			done := make(chan struct{})
+			panicChan := make(chan any, 1)
+			go func() {
+				defer func() {
+					p := recover()
+					if p == nil {
+						return
+					}
+
+					tw.mu.Lock()
+					defer tw.mu.Unlock()
+					if !tw.timedOut {
+						panicChan <- p
+						return
+					}
+					// the request has been responded, the panic is reported instead
+					if err, ok := p.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+						return
+					}
+					sink.ReportPanic(req, p, debug.Stack())
+				}()
+				next.ServeHTTP(tw, req)
+				close(done)
+			}()
+
+			select {
+			case p := <-panicChan:
+				panic(p)
+			case <-done:
+			case <-ctx.Done():
+			}
+
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			// the handler panicked before the timeout
+			select {
+			case p := <-panicChan:
+				panic(p)
+			default:
+			}
+			// a handler returning after the deadline has its response discarded as well
+			if err := ctx.Err(); err != nil {
+				tw.timedOut = true
+				if errors.Is(err, context.DeadlineExceeded) {
+					render.WriteProblem(w, req, render.NewProblem(status, "request timed out")) // nolint:errcheck
+				}
+				return
+			}
+			dst := w.Header()
+			for k, v := range tw.header {
+				dst[k] = v
+			}
+			if tw.status == 0 {
+				tw.status = http.StatusOK
+			}
+			w.WriteHeader(tw.status)
+			w.Write(tw.buf.Bytes()) // nolint:errcheck
+		})
+	}
+}
+
+// timeoutWriter buffers the handler's response, writes after the timeout return http.ErrHandlerTimeout.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	status   int
+	timedOut bool
+}
+
+// Header implements http.ResponseWriter.
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// Write implements http.ResponseWriter.
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.buf.Write(b)
+}
+
+// WriteHeader implements http.ResponseWriter, informational statuses are not written.
+func (tw *timeoutWriter) WriteHeader(statusCode int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.status != 0 || (statusCode >= 100 && statusCode <= 199) {
+		return
+	}
+	tw.status = statusCode
+}
+
+// parseRequestTimeout parses the requested timeout, in seconds or a duration.
+func parseRequestTimeout(v string) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		if secs <= 0 || secs > float64(1<<63-1)/float64(time.Second) {
+			return 0, false
+		}
+		return time.Duration(secs * float64(time.Second)), true
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, false
+	}
+	return d, true
+}
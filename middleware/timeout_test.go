@@ -0,0 +1,179 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sanekee/limi"
+	"github.com/sanekee/limi/internal/testing/require"
+)
+
+func TestTimeout(t *testing.T) {
+	sleep := func(d time.Duration) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			select {
+			case <-time.After(d):
+			case <-req.Context().Done():
+			}
+			w.Header().Set("X-Late", "true")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("done")) // nolint:errcheck
+		}
+	}
+
+	t.Run("completed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		Timeout(time.Second, TimeoutOptions{})(sleep(0)).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		require.Equal(t, http.StatusCreated, rec.Code)
+		require.Equal(t, "true", rec.Header().Get("X-Late"))
+		require.Equal(t, "done", rec.Body.String())
+	})
+
+	t.Run("timed out", func(t *testing.T) {
+		release := make(chan struct{})
+		writeErr := make(chan error, 1)
+		handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			<-release
+			w.Header().Set("X-Late", "true")
+			_, err := w.Write([]byte("late"))
+			writeErr <- err
+		})
+		rec := httptest.NewRecorder()
+		Timeout(10*time.Millisecond, TimeoutOptions{})(handler).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		close(release)
+
+		require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		require.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+		require.Empty(t, rec.Header().Get("X-Late"))
+		require.True(t, errors.Is(<-writeErr, http.ErrHandlerTimeout))
+	})
+
+	t.Run("returned after deadline", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		Timeout(10*time.Millisecond, TimeoutOptions{})(sleep(time.Second)).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		require.Empty(t, rec.Header().Get("X-Late"))
+	})
+
+	t.Run("gateway timeout", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		Timeout(10*time.Millisecond, TimeoutOptions{Status: http.StatusGatewayTimeout})(sleep(time.Second)).
+			ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, http.StatusGatewayTimeout, rec.Code)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		rec := httptest.NewRecorder()
+		Timeout(time.Second, TimeoutOptions{})(sleep(time.Second)).
+			ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+		require.Empty(t, rec.Body.String())
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		defer func() {
+			require.Equal(t, "boom", recover())
+		}()
+		Timeout(time.Second, TimeoutOptions{})(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			panic("boom")
+		})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+
+	t.Run("panic after timeout", func(t *testing.T) {
+		release := make(chan struct{})
+		reported := make(chan any, 1)
+		sink := PanicSinkFunc(func(req *http.Request, v any, stack []byte) {
+			require.NotEmpty(t, stack)
+			reported <- v
+		})
+
+		rec := httptest.NewRecorder()
+		Timeout(10*time.Millisecond, TimeoutOptions{Sink: sink})(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			<-release
+			panic("boom")
+		})).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		close(release)
+
+		require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		require.Equal(t, "boom", <-reported)
+	})
+
+	t.Run("requested timeout", func(t *testing.T) {
+		var deadline time.Duration
+		handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			d, ok := req.Context().Deadline()
+			deadline = 0
+			if ok {
+				deadline = time.Until(d)
+			}
+		})
+
+		tests := []struct {
+			timeout  time.Duration
+			opts     TimeoutOptions
+			header   string
+			expected time.Duration
+		}{
+			{time.Minute, TimeoutOptions{}, "1", time.Minute},
+			{time.Minute, TimeoutOptions{MaxTimeout: time.Hour}, "1", time.Second},
+			{time.Minute, TimeoutOptions{MaxTimeout: time.Hour}, "500ms", 500 * time.Millisecond},
+			{time.Second, TimeoutOptions{MaxTimeout: time.Minute}, "10", time.Second},
+			{time.Second, TimeoutOptions{MaxTimeout: time.Minute}, "3600", time.Second},
+			{time.Second, TimeoutOptions{MaxTimeout: time.Minute, Header: "X-Timeout"}, "0.5", 500 * time.Millisecond},
+			{time.Second, TimeoutOptions{MaxTimeout: time.Minute}, "invalid", time.Second},
+			{time.Second, TimeoutOptions{MaxTimeout: time.Minute}, "-1", time.Second},
+			{0, TimeoutOptions{}, "10", 0},
+			{0, TimeoutOptions{MaxTimeout: time.Minute}, "10", 10 * time.Second},
+			{0, TimeoutOptions{MaxTimeout: time.Minute}, "3600", time.Minute},
+		}
+		for _, tc := range tests {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			header := tc.opts.Header
+			if header == "" {
+				header = "Request-Timeout"
+			}
+			req.Header.Set(header, tc.header)
+			Timeout(tc.timeout, tc.opts)(handler).ServeHTTP(httptest.NewRecorder(), req)
+
+			require.True(t, deadline <= tc.expected && deadline > tc.expected-100*time.Millisecond)
+		}
+	})
+
+	t.Run("route timeout", func(t *testing.T) {
+		r, err := limi.NewRouter("/", limi.WithMiddlewares(Timeout(time.Minute, TimeoutOptions{})))
+		require.NoError(t, err)
+		require.NoError(t, r.AddHandler(testReportHandler{}))
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reports", nil))
+		require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	})
+
+	t.Run("requested timeout does not extend the route timeout", func(t *testing.T) {
+		r, err := limi.NewRouter("/", limi.WithMiddlewares(Timeout(time.Minute, TimeoutOptions{MaxTimeout: time.Hour})))
+		require.NoError(t, err)
+		require.NoError(t, r.AddHandler(testReportHandler{}))
+
+		req := httptest.NewRequest(http.MethodGet, "/reports", nil)
+		req.Header.Set("Request-Timeout", "3600")
+		rec := httptest.NewRecorder()
+		start := time.Now()
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		require.True(t, time.Since(start) < time.Second)
+	})
+}
+
+type testReportHandler struct {
+	_ struct{} `limi:"path=/reports,timeout=10ms"`
+}
+
+func (testReportHandler) Get(w http.ResponseWriter, req *http.Request) {
+	<-req.Context().Done()
+}
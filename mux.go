@@ -9,21 +9,25 @@ import (
 )
 
 // mux is the router multiplexer.
+// Routers are indexed by their hosts, a request is dispatched only to the routers supporting the request host.
+// Routers with overlapping hosts are tried in the order they were added.
 type mux struct {
 	routers         []*Router
+	hosts           hostIndex
 	notFoundHandler http.Handler
 }
 
 // NewMux creates a new router multiplexer with a list of routers.
 func NewMux(routers ...*Router) *mux {
-	return &mux{
-		routers: routers,
-	}
+	m := &mux{}
+	return m.AddRouters(routers...)
 }
 
 // AddRouters adds a list of routers to the multiplexer
 func (m *mux) AddRouters(routers ...*Router) *mux {
-	m.routers = append(m.routers, routers...)
+	for _, r := range routers {
+		m.addRouter(r)
+	}
 	return m
 }
 
@@ -34,10 +38,15 @@ func (m *mux) AddRouter(path string, opts ...RouterOptions) (*Router, error) {
 		return nil, fmt.Errorf("error adding router %w", err)
 	}
 
-	m.routers = append(m.routers, r)
+	m.addRouter(r)
 	return r, nil
 }
 
+func (m *mux) addRouter(r *Router) {
+	m.hosts.add(len(m.routers), r)
+	m.routers = append(m.routers, r)
+}
+
 // SetNotFoundHandler sets the not found handler.
 func (m *mux) SetNotFoundHandler(h http.Handler) *mux {
 	m.notFoundHandler = h
@@ -57,9 +66,25 @@ func (m *mux) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	}
 
 	var lastNotAllowedHandle limi.Handle
-	for _, r := range m.routers {
-		if r.IsSupportedHost(ctx, parseHost(req.Host)) {
-			h, _ := r.lookup(ctx, req.URL.Path)
+	var notFoundHandler http.Handler
+	host := req.Host
+	var it hostIter
+	m.hosts.lookup(host, &it)
+	for e, params, ok := it.next(); ok; e, params, ok = it.next() {
+		r := m.routers[e.index]
+		if e.indexed {
+			limi.SetHostParams(ctx, params)
+		}
+		if e.indexed || r.IsSupportedHost(ctx, host) {
+			limi.SetNotFoundHandler(ctx, m.notFoundHandler)
+			h, _, subNotFoundHandler, redirectPath := r.match(ctx, host, req.URL.Path)
+			if redirectPath != "" {
+				r.redirect(w, req, redirectPath)
+				return
+			}
+			if h == nil && notFoundHandler == nil {
+				notFoundHandler = subNotFoundHandler
+			}
 			if h != nil {
 				if !h.IsMethodAllowed(req.Method) {
 					lastNotAllowedHandle = h
@@ -79,6 +104,12 @@ func (m *mux) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	// subrouter's not found handler
+	if notFoundHandler != nil {
+		notFoundHandler.ServeHTTP(w, req)
+		return
+	}
+
 	if m.notFoundHandler != nil {
 		m.notFoundHandler.ServeHTTP(w, req)
 		return
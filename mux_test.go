@@ -4,6 +4,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 
 	"github.com/sanekee/limi/internal/testing/handler"
@@ -217,6 +218,49 @@ func TestMux(t *testing.T) {
 		require.Equal(t, 2, notAllowedRouter)
 	})
 
+	t.Run("multi routes - sub route not found handler", func(t *testing.T) {
+		m := NewMux()
+		r1, err := m.AddRouter("/")
+		require.NoError(t, err)
+
+		_, err = r1.AddRouter("/api", WithNotFoundHandler(handler.NewHandler(http.StatusNotFound, nil, []byte("json"))))
+		require.NoError(t, err)
+
+		r2, err := m.AddRouter("/")
+		require.NoError(t, err)
+
+		err = r2.AddHandlerFunc("/api/foo", http.MethodGet, handler.NewHandlerFunc(http.StatusOK, nil, []byte("foo")))
+		require.NoError(t, err)
+
+		m.SetNotFoundHandler(handler.NewHandler(http.StatusNotFound, nil, []byte("html")))
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://localhost:9090/api/foo", nil)
+
+		m.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "http://localhost:9090/api/bar", nil)
+
+		m.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
+
+		body, err := io.ReadAll(rec.Body)
+		require.NoError(t, err)
+		require.Equal(t, "json", string(body))
+
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "http://localhost:9090/bar", nil)
+
+		m.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
+
+		body, err = io.ReadAll(rec.Body)
+		require.NoError(t, err)
+		require.Equal(t, "html", string(body))
+	})
+
 	t.Run("multi routes with existing routers", func(t *testing.T) {
 		r1, err := NewRouter("/")
 		require.NoError(t, err)
@@ -288,4 +332,135 @@ func TestMux(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, "bar", string(body))
 	})
+
+	t.Run("multi routes - overlapping hosts", func(t *testing.T) {
+		m := NewMux()
+		r1, err := m.AddRouter("/", WithHosts("{tenant}.example.com"))
+		require.NoError(t, err)
+
+		err = r1.AddHandlerFunc("/foo", http.MethodGet, handler.NewHandlerFunc(http.StatusOK, nil, []byte("foo1")))
+		require.NoError(t, err)
+
+		r2, err := m.AddRouter("/", WithHosts("tenant1.example.com"))
+		require.NoError(t, err)
+
+		err = r2.AddHandlerFunc("/foo", http.MethodGet, handler.NewHandlerFunc(http.StatusOK, nil, []byte("foo2")))
+		require.NoError(t, err)
+
+		err = r2.AddHandlerFunc("/bar", http.MethodGet, handler.NewHandlerFunc(http.StatusOK, nil, []byte("bar2")))
+		require.NoError(t, err)
+
+		r3, err := m.AddRouter("/")
+		require.NoError(t, err)
+
+		err = r3.AddHandlerFunc("/baz", http.MethodGet, handler.NewHandlerFunc(http.StatusOK, nil, []byte("baz3")))
+		require.NoError(t, err)
+
+		testCases := []struct {
+			url    string
+			status int
+			body   string
+		}{
+			{url: "http://tenant1.example.com/foo", status: http.StatusOK, body: "foo1"}, // first added router
+			{url: "http://tenant1.example.com/bar", status: http.StatusOK, body: "bar2"}, // fallback to the next router
+			{url: "http://tenant2.example.com/baz", status: http.StatusOK, body: "baz3"}, // fallback to router without hosts
+			{url: "http://tenant2.example.com/bar", status: http.StatusNotFound},
+			{url: "http://example.org/foo", status: http.StatusNotFound},
+		}
+
+		for _, tc := range testCases {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.url, nil)
+
+			m.ServeHTTP(rec, req)
+			require.Equal(t, tc.status, rec.Result().StatusCode)
+
+			if tc.body != "" {
+				body, err := io.ReadAll(rec.Body)
+				require.NoError(t, err)
+				require.Equal(t, tc.body, string(body))
+			}
+		}
+	})
+
+	t.Run("multi routes - many hosts", func(t *testing.T) {
+		m := NewMux()
+		for i := 0; i < 50; i++ {
+			tenant := "tenant" + strconv.Itoa(i)
+			r, err := m.AddRouter("/", WithHosts(tenant+".example.com", tenant+".example.org:8080"))
+			require.NoError(t, err)
+
+			err = r.AddHandlerFunc("/foo", http.MethodGet, handler.NewHandlerFunc(http.StatusOK, nil, []byte(tenant)))
+			require.NoError(t, err)
+		}
+
+		for _, host := range []string{"tenant42.example.com", "tenant42.example.org:8080"} {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "http://"+host+"/foo", nil)
+
+			m.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+			body, err := io.ReadAll(rec.Body)
+			require.NoError(t, err)
+			require.Equal(t, "tenant42", string(body))
+		}
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://tenant42.example.org/foo", nil)
+
+		m.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
+	})
+}
+
+func TestHostIndex(t *testing.T) {
+	r1, err := NewRouter("/", WithHosts("{tenant}.example.com"))
+	require.NoError(t, err)
+
+	r2, err := NewRouter("/")
+	require.NoError(t, err)
+
+	r3, err := NewRouter("/", WithHosts("tenant1.example.com", "admin.example.com:{port}"))
+	require.NoError(t, err)
+
+	var x hostIndex
+	x.add(0, r1)
+	x.add(1, r2)
+	x.add(2, r3)
+
+	lookup := func(host string) ([]hostEntry, []map[string]string) {
+		var it hostIter
+		x.lookup(host, &it)
+		var entries []hostEntry
+		var params []map[string]string
+		for e, p, ok := it.next(); ok; e, p, ok = it.next() {
+			entries = append(entries, e)
+			params = append(params, p)
+		}
+		return entries, params
+	}
+
+	entries, params := lookup("Tenant1.Example.com")
+	require.Equal(t, []hostEntry{{0, true}, {1, false}, {2, true}}, entries)
+	require.Equal(t, []map[string]string{{"tenant": "tenant1"}, nil, nil}, params)
+
+	entries, params = lookup("tenant2.example.com")
+	require.Equal(t, []hostEntry{{0, true}, {1, false}}, entries)
+	require.Equal(t, []map[string]string{{"tenant": "tenant2"}, nil}, params)
+
+	entries, params = lookup("admin.example.com:8081")
+	require.Equal(t, []hostEntry{{0, true}, {1, false}, {2, true}}, entries)
+	require.Equal(t, []map[string]string{{"tenant": "admin"}, nil, {"port": "8081"}}, params)
+
+	entries, _ = lookup("example.org")
+	require.Equal(t, []hostEntry{{1, false}}, entries)
+
+	// routers added after the indexed routers are ordered in the indexed hosts
+	r4, err := NewRouter("/")
+	require.NoError(t, err)
+	x.add(3, r4)
+
+	entries, _ = lookup("tenant2.example.com")
+	require.Equal(t, []hostEntry{{0, true}, {1, false}, {3, false}}, entries)
 }
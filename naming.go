@@ -0,0 +1,67 @@
+package limi
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/sanekee/limi/internal/limi"
+)
+
+// NamingStrategy converts the handler's struct name to the routing path segment, i.e. `TeamMerchants` to `team-merchants`.
+type NamingStrategy func(name string) string
+
+var (
+	// Lowercase converts the name to lower case, i.e. `TeamMerchants` to `teammerchants`. This is the default naming strategy.
+	Lowercase NamingStrategy = strings.ToLower
+	// KebabCase converts the name to kebab case, i.e. `TeamMerchants` to `team-merchants`.
+	KebabCase NamingStrategy = func(name string) string {
+		return strings.Join(splitWords(name), "-")
+	}
+	// SnakeCase converts the name to snake case, i.e. `TeamMerchants` to `team_merchants`.
+	SnakeCase NamingStrategy = func(name string) string {
+		return strings.Join(splitWords(name), "_")
+	}
+)
+
+// WithNamingStrategy set the naming strategy of the handlers' struct names in discovered routing paths, default is Lowercase.
+// Index handlers (i.e. the struct with the package name or named `Index`) are not affected.
+func WithNamingStrategy(ns NamingStrategy) RouterOptions {
+	return func(r *Router) error {
+		if ns == nil {
+			return fmt.Errorf("missing naming strategy %w", limi.ErrInvalidInput)
+		}
+		r.naming = ns
+		return nil
+	}
+}
+
+// splitWords splits a camel case name into lower case words, i.e. `HTTPServerV2` to [http, server, v2].
+func splitWords(name string) []string {
+	runes := []rune(name)
+
+	var words []string
+	var word []rune
+	for i, c := range runes {
+		if i > 0 && unicode.IsUpper(c) {
+			prev := runes[i-1]
+			isAcronymEnd := unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || isAcronymEnd {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+		if c == '_' || c == '-' {
+			if len(word) > 0 {
+				words = append(words, string(word))
+			}
+			word = nil
+			continue
+		}
+		word = append(word, unicode.ToLower(c))
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
@@ -0,0 +1,51 @@
+package limi
+
+import (
+	"testing"
+
+	"github.com/sanekee/limi/internal/testing/require"
+)
+
+func TestNamingStrategy(t *testing.T) {
+	type test struct {
+		name          string
+		expectedKebab string
+		expectedSnake string
+	}
+
+	tests := []test{
+		{name: "Foo", expectedKebab: "foo", expectedSnake: "foo"},
+		{name: "TeamMerchants", expectedKebab: "team-merchants", expectedSnake: "team_merchants"},
+		{name: "HTTPServer", expectedKebab: "http-server", expectedSnake: "http_server"},
+		{name: "ItemsV2", expectedKebab: "items-v2", expectedSnake: "items_v2"},
+		{name: "V2Items", expectedKebab: "v2-items", expectedSnake: "v2_items"},
+		{name: "userID", expectedKebab: "user-id", expectedSnake: "user_id"},
+		{name: "Team_Merchants", expectedKebab: "team-merchants", expectedSnake: "team_merchants"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expectedKebab, KebabCase(tt.name))
+			require.Equal(t, tt.expectedSnake, SnakeCase(tt.name))
+		})
+	}
+
+	t.Run("resolve paths", func(t *testing.T) {
+		roots := []handlerRoot{{path: "handler"}}
+		require.Equal(t, []string{"/teams/team-merchants"}, resolvePaths(testTyper{pkgPath: "/pkg/handler/teams", name: "TeamMerchants"}, roots, KebabCase))
+		require.Equal(t, []string{"/teams/team_merchants"}, resolvePaths(testTyper{pkgPath: "/pkg/handler/teams", name: "TeamMerchants"}, roots, SnakeCase))
+		require.Equal(t, []string{"/teams/TeamMerchants"}, resolvePaths(testTyper{pkgPath: "/pkg/handler/teams", name: "TeamMerchants"}, roots, func(s string) string { return s }))
+		// index handlers
+		require.Equal(t, []string{"/team_merchants"}, resolvePaths(testTyper{pkgPath: "/pkg/handler/team_merchants", name: "Team_Merchants"}, roots, KebabCase))
+		require.Equal(t, []string{"/teams"}, resolvePaths(testTyper{pkgPath: "/pkg/handler/teams", name: "Index"}, roots, KebabCase))
+	})
+
+	t.Run("option", func(t *testing.T) {
+		r, err := NewRouter("/", WithNamingStrategy(KebabCase))
+		require.NoError(t, err)
+		require.Equal(t, "team-merchants", r.naming("TeamMerchants"))
+
+		_, err = NewRouter("/", WithNamingStrategy(nil))
+		require.Error(t, err)
+	})
+}
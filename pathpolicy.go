@@ -0,0 +1,129 @@
+package limi
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/sanekee/limi/internal/limi"
+)
+
+// TrailingSlashMode is the router's behavior for paths with or without the trailing slash.
+type TrailingSlashMode int
+
+const (
+	// TrailingSlashStrict matches the path as is, `/foo` and `/foo/` are different paths.
+	TrailingSlashStrict TrailingSlashMode = iota
+	// TrailingSlashRedirect redirects to the path with or without the trailing slash when only the other path is found.
+	TrailingSlashRedirect
+	// TrailingSlashLenient handles the path with or without the trailing slash when only the other path is found.
+	TrailingSlashLenient
+)
+
+// PathPolicy is the router's path canonicalization policy.
+type PathPolicy struct {
+	// TrailingSlash is the trailing slash matching mode, default is TrailingSlashStrict.
+	TrailingSlash TrailingSlashMode
+	// CleanPath redirects to the path cleaned with path.Clean (i.e. `//foo` and `/bar/../foo` are redirected to `/foo`).
+	CleanPath bool
+	// CaseInsensitive matches the string pattern case insensitively, labels and regexps are not affected.
+	CaseInsensitive bool
+	// RedirectCode is the redirect status code, default is 301 for GET and HEAD, 308 for other methods to preserve the method.
+	RedirectCode int
+}
+
+// WithPathPolicy set Router's path canonicalization policy, subrouters inherit the parent's path policy.
+func WithPathPolicy(p PathPolicy) RouterOptions {
+	return func(r *Router) error {
+		r.pathPolicy = p
+		return nil
+	}
+}
+
+// match lookups for a Handle to host and path with the router's path policy.
+// Returns a non empty canonical path when the request should be redirected.
+func (r *Router) match(ctx context.Context, host string, path string) (limi.Handle, string, http.Handler, string) {
+	if r.pathPolicy.CleanPath && strings.HasPrefix(path, "/") {
+		if cleaned := cleanPath(path); cleaned != path {
+			return nil, "", nil, cleaned
+		}
+	}
+
+	path, versionIdx, version := r.versioning.stripVersion(ctx, r.versionPrefix(), path)
+
+	// params of the first lookup are discarded before the trailing slash lookup
+	saved := limi.SaveParams(ctx)
+	h, trail, notFoundHandler := r.lookup(ctx, host, path)
+	if h != nil ||
+		r.pathPolicy.TrailingSlash == TrailingSlashStrict ||
+		path == "" || path == "/" {
+		return h, trail, notFoundHandler, ""
+	}
+
+	limi.RestoreParams(ctx, saved)
+	altPath := toggleTrailingSlash(path)
+	altH, altTrail, _ := r.lookup(ctx, host, altPath)
+	if altH == nil {
+		limi.RestoreParams(ctx, saved)
+		return nil, trail, notFoundHandler, ""
+	}
+
+	if r.pathPolicy.TrailingSlash == TrailingSlashRedirect {
+		return nil, "", nil, restoreVersion(altPath, versionIdx, version)
+	}
+	return altH, altTrail, nil, ""
+}
+
+// versionPrefix returns the path prefix before the version segment with the VersionPath strategy.
+func (r *Router) versionPrefix() string {
+	if r.isSubRoute {
+		return ""
+	}
+	return buildPath(r.path, "")
+}
+
+// nodeLookup lookups the router's node with the router's path policy.
+func (r *Router) nodeLookup(ctx context.Context, path string) (limi.Handle, string) {
+	if r.pathPolicy.CaseInsensitive {
+		return r.node.LookupFold(ctx, path)
+	}
+	return r.node.Lookup(ctx, path)
+}
+
+// redirect redirects the request to path with the router's redirect code.
+func (r *Router) redirect(w http.ResponseWriter, req *http.Request, path string) {
+	code := r.pathPolicy.RedirectCode
+	if code == 0 {
+		code = http.StatusMovedPermanently
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			code = http.StatusPermanentRedirect
+		}
+	}
+
+	// prevent redirecting to a scheme relative url
+	if strings.HasPrefix(path, "//") {
+		path = "/" + strings.TrimLeft(path, "/")
+	}
+
+	u := url.URL{Path: path, RawQuery: req.URL.RawQuery}
+	http.Redirect(w, req, u.String(), code)
+}
+
+// cleanPath returns the path cleaned with path.Clean, preserving the trailing slash.
+func cleanPath(p string) string {
+	cleaned := path.Clean(p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// toggleTrailingSlash returns the path with the trailing slash added or removed.
+func toggleTrailingSlash(path string) string {
+	if strings.HasSuffix(path, "/") {
+		return removeTraillingSlash(path)
+	}
+	return ensureTrailingSlash(path)
+}
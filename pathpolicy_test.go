@@ -0,0 +1,225 @@
+package limi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sanekee/limi/internal/testing/handler"
+	"github.com/sanekee/limi/internal/testing/require"
+)
+
+func TestCleanPath(t *testing.T) {
+	type test struct {
+		input    string
+		expected string
+	}
+
+	tests := []test{
+		{input: "/", expected: "/"},
+		{input: "/foo", expected: "/foo"},
+		{input: "/foo/", expected: "/foo/"},
+		{input: "//foo", expected: "/foo"},
+		{input: "/a/../foo", expected: "/foo"},
+		{input: "/foo/./bar//", expected: "/foo/bar/"},
+		{input: "/..", expected: "/"},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			require.Equal(t, tt.expected, cleanPath(tt.input))
+		})
+	}
+}
+
+func TestPathPolicy(t *testing.T) {
+	newRouter := func(t *testing.T, p PathPolicy) *Router {
+		r, err := NewRouter("/", WithPathPolicy(p))
+		require.NoError(t, err)
+
+		err = r.Methods("/teams", []string{http.MethodGet, http.MethodPost}, handler.NewHandlerFunc(http.StatusOK, nil, []byte("teams")))
+		require.NoError(t, err)
+
+		err = r.AddHandlerFunc("/merchants/", http.MethodGet, handler.NewHandlerFunc(http.StatusOK, nil, []byte("merchants")))
+		require.NoError(t, err)
+
+		err = r.AddHandlerFunc("/teams/{id}", http.MethodGet, func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(GetURLParam(req.Context(), "id"))) // nolint:errcheck
+		})
+		require.NoError(t, err)
+
+		return r
+	}
+
+	t.Run("strict", func(t *testing.T) {
+		r := newRouter(t, PathPolicy{})
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/teams/", nil)
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
+
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "http://localhost//teams", nil)
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
+	})
+
+	t.Run("trailing slash redirect", func(t *testing.T) {
+		r := newRouter(t, PathPolicy{TrailingSlash: TrailingSlashRedirect})
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/teams/?offset=1", nil)
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusMovedPermanently, rec.Result().StatusCode)
+		require.Equal(t, "/teams?offset=1", rec.Header().Get("Location"))
+
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodPost, "http://localhost/teams/", nil)
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusPermanentRedirect, rec.Result().StatusCode)
+		require.Equal(t, "/teams", rec.Header().Get("Location"))
+
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "http://localhost/merchants", nil)
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusMovedPermanently, rec.Result().StatusCode)
+		require.Equal(t, "/merchants/", rec.Header().Get("Location"))
+
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "http://localhost/foo/", nil)
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
+	})
+
+	t.Run("trailing slash redirect with code", func(t *testing.T) {
+		r := newRouter(t, PathPolicy{TrailingSlash: TrailingSlashRedirect, RedirectCode: http.StatusPermanentRedirect})
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/teams/", nil)
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusPermanentRedirect, rec.Result().StatusCode)
+	})
+
+	t.Run("trailing slash lenient", func(t *testing.T) {
+		r := newRouter(t, PathPolicy{TrailingSlash: TrailingSlashLenient})
+
+		for _, url := range []string{"http://localhost/teams", "http://localhost/teams/"} {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, url, nil)
+			r.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+			body, err := io.ReadAll(rec.Body)
+			require.NoError(t, err)
+			require.Equal(t, "teams", string(body))
+		}
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/merchants", nil)
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		body, err := io.ReadAll(rec.Body)
+		require.NoError(t, err)
+		require.Equal(t, "merchants", string(body))
+	})
+
+	t.Run("trailing slash lenient params", func(t *testing.T) {
+		r := newRouter(t, PathPolicy{TrailingSlash: TrailingSlashLenient})
+
+		err := r.AddHandlerFunc("/teams/{id}/members/{memberId}", http.MethodGet, handler.NewHandlerFunc(http.StatusOK, nil, []byte("member")))
+		require.NoError(t, err)
+
+		err = r.AddHandlerFunc("/teams/all/members", http.MethodGet, func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("all " + GetURLParam(req.Context(), "id"))) // nolint:errcheck
+		})
+		require.NoError(t, err)
+
+		// the first lookup partially matches `/teams/{id}/members/{memberId}`
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/teams/all/members/", nil)
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		body, err := io.ReadAll(rec.Body)
+		require.NoError(t, err)
+		require.Equal(t, "all ", string(body))
+	})
+
+	t.Run("clean path", func(t *testing.T) {
+		r := newRouter(t, PathPolicy{CleanPath: true})
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/a/../teams", nil)
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusMovedPermanently, rec.Result().StatusCode)
+		require.Equal(t, "/teams", rec.Header().Get("Location"))
+
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "http://localhost//evil.com/", nil)
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusMovedPermanently, rec.Result().StatusCode)
+		require.Equal(t, "/evil.com/", rec.Header().Get("Location"))
+	})
+
+	t.Run("case insensitive", func(t *testing.T) {
+		r := newRouter(t, PathPolicy{CaseInsensitive: true})
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/TEAMS/Abc", nil)
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		body, err := io.ReadAll(rec.Body)
+		require.NoError(t, err)
+		require.Equal(t, "Abc", string(body))
+	})
+
+	t.Run("subrouter inherits policy", func(t *testing.T) {
+		r, err := NewRouter("/", WithPathPolicy(PathPolicy{TrailingSlash: TrailingSlashLenient, CaseInsensitive: true}))
+		require.NoError(t, err)
+
+		r1, err := r.AddRouter("/api")
+		require.NoError(t, err)
+
+		err = r1.AddHandlerFunc("/foo", http.MethodGet, handler.NewHandlerFunc(http.StatusOK, nil, []byte("foo")))
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/API/Foo/", nil)
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+	})
+
+	t.Run("mux", func(t *testing.T) {
+		m := NewMux()
+		r1, err := m.AddRouter("/")
+		require.NoError(t, err)
+
+		err = r1.AddHandlerFunc("/foo", http.MethodGet, handler.NewHandlerFunc(http.StatusOK, nil, []byte("foo")))
+		require.NoError(t, err)
+
+		r2, err := m.AddRouter("/", WithPathPolicy(PathPolicy{TrailingSlash: TrailingSlashRedirect, CleanPath: true}))
+		require.NoError(t, err)
+
+		err = r2.AddHandlerFunc("/bar", http.MethodGet, handler.NewHandlerFunc(http.StatusOK, nil, []byte("bar")))
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/bar/", nil)
+		m.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusMovedPermanently, rec.Result().StatusCode)
+		require.Equal(t, "/bar", rec.Header().Get("Location"))
+
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "http://localhost/foo/", nil)
+		m.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
+	})
+}
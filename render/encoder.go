@@ -0,0 +1,214 @@
+package render
+
+import (
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// Encoder encodes a value into a media type.
+type Encoder interface {
+	// ContentType returns the content type of the encoded value, i.e. `application/json; charset=utf-8`.
+	ContentType() string
+	// Encode writes the encoded v to w, returns ErrUnsupportedValue when v can't be encoded.
+	Encode(w io.Writer, v any) error
+}
+
+// NewEncoder returns an Encoder with content type and encode function.
+func NewEncoder(contentType string, fn func(w io.Writer, v any) error) Encoder {
+	return encoderFunc{
+		contentType: contentType,
+		fn:          fn,
+	}
+}
+
+type encoderFunc struct {
+	contentType string
+	fn          func(w io.Writer, v any) error
+}
+
+// ContentType implements Encoder.
+func (e encoderFunc) ContentType() string {
+	return e.contentType
+}
+
+// Encode implements Encoder.
+func (e encoderFunc) Encode(w io.Writer, v any) error {
+	return e.fn(w, v)
+}
+
+// JSON returns the `application/json` encoder.
+func JSON() Encoder {
+	return NewEncoder("application/json; charset=utf-8", func(w io.Writer, v any) error {
+		return json.NewEncoder(w).Encode(v)
+	})
+}
+
+// XML returns the `application/xml` encoder, values not supported by encoding/xml (i.e. maps) are unsupported.
+func XML() Encoder {
+	return NewEncoder("application/xml; charset=utf-8", func(w io.Writer, v any) error {
+		if v == nil {
+			return nil
+		}
+		if rv := reflect.Indirect(reflect.ValueOf(v)); rv.Kind() == reflect.Map {
+			return fmt.Errorf("xml %w", ErrUnsupportedValue)
+		}
+
+		if _, err := io.WriteString(w, xml.Header); err != nil {
+			return err
+		}
+		if err := xml.NewEncoder(w).Encode(v); err != nil {
+			var uErr *xml.UnsupportedTypeError
+			if errors.As(err, &uErr) {
+				return fmt.Errorf("xml %w, %s", ErrUnsupportedValue, err.Error())
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+// CSVMarshaler is implemented by values encoding themselves as CSV records.
+type CSVMarshaler interface {
+	MarshalCSV() ([][]string, error)
+}
+
+// CSV returns the `text/csv` encoder.
+// Supported values are CSVMarshaler, [][]string, and slices of structs, encoded with a header of the field names or `csv` struct tags.
+func CSV() Encoder {
+	return NewEncoder("text/csv; charset=utf-8", func(w io.Writer, v any) error {
+		records, err := csvRecords(v)
+		if err != nil {
+			return err
+		}
+
+		cw := csv.NewWriter(w)
+		if err := cw.WriteAll(records); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+// Text returns the `text/plain` encoder.
+// Supported values are string, []byte, encoding.TextMarshaler, fmt.Stringer, error and scalar values.
+func Text() Encoder {
+	return NewEncoder("text/plain; charset=utf-8", func(w io.Writer, v any) error {
+		var str string
+		switch t := v.(type) {
+		case nil:
+			return nil
+		case string:
+			str = t
+		case []byte:
+			_, err := w.Write(t)
+			return err
+		case encoding.TextMarshaler:
+			b, err := t.MarshalText()
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(b)
+			return err
+		case fmt.Stringer:
+			str = t.String()
+		case error:
+			str = t.Error()
+		default:
+			switch reflect.Indirect(reflect.ValueOf(v)).Kind() {
+			case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+				reflect.Float32, reflect.Float64, reflect.String:
+				str = fmt.Sprint(reflect.Indirect(reflect.ValueOf(v)).Interface())
+			default:
+				return fmt.Errorf("text %w", ErrUnsupportedValue)
+			}
+		}
+		_, err := io.WriteString(w, str)
+		return err
+	})
+}
+
+// csvRecords returns the csv records of v.
+func csvRecords(v any) ([][]string, error) {
+	switch t := v.(type) {
+	case CSVMarshaler:
+		return t.MarshalCSV()
+	case [][]string:
+		return t, nil
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("csv %w", ErrUnsupportedValue)
+	}
+
+	et := rv.Type().Elem()
+	if et.Kind() == reflect.Pointer {
+		et = et.Elem()
+	}
+	if et.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("csv %w", ErrUnsupportedValue)
+	}
+
+	var fields []int
+	var header []string
+	for i := 0; i < et.NumField(); i++ {
+		f := et.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Name
+		if tag := f.Tag.Get("csv"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			name = strings.Split(tag, ",")[0]
+		}
+		fields = append(fields, i)
+		header = append(header, name)
+	}
+
+	records := [][]string{header}
+	for i := 0; i < rv.Len(); i++ {
+		ev := reflect.Indirect(rv.Index(i))
+		record := make([]string, 0, len(fields))
+		for _, fi := range fields {
+			if !ev.IsValid() {
+				record = append(record, "")
+				continue
+			}
+			record = append(record, csvValue(ev.Field(fi)))
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// csvValue returns the string value of a csv field.
+func csvValue(v reflect.Value) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	switch t := v.Interface().(type) {
+	case encoding.TextMarshaler:
+		b, err := t.MarshalText()
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	case fmt.Stringer:
+		return t.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
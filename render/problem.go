@@ -0,0 +1,109 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/sanekee/limi/internal/limi"
+)
+
+const (
+	problemContentType = "application/problem+json"
+	// problemRequestID is the extension member of the request ID set by the RequestID middleware.
+	problemRequestID = "requestId"
+)
+
+// Problem is the RFC 9457 problem details of an error response.
+type Problem struct {
+	// Type is the URI reference identifying the problem type, default is `about:blank`.
+	Type string `json:"type,omitempty"`
+	// Title is the short summary of the problem type, default is the status text.
+	Title string `json:"title,omitempty"`
+	// Status is the http status code.
+	Status int `json:"status"`
+	// Detail is the explanation specific to the occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+	// Instance is the URI reference identifying the occurrence of the problem.
+	Instance string `json:"instance,omitempty"`
+	// Extensions are the additional members of the problem details.
+	Extensions map[string]any `json:"-"`
+}
+
+// NewProblem returns a Problem with status and detail.
+func NewProblem(status int, detail string) Problem {
+	return Problem{
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// MarshalJSON implements json.Marshaler, extensions are encoded as top level members.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	type problem Problem
+	b, err := json.Marshal(problem(p))
+	if err != nil {
+		return nil, err
+	}
+	if len(p.Extensions) == 0 {
+		return b, nil
+	}
+
+	m := make(map[string]any, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return json.Marshal(m)
+}
+
+// Error implements error.
+func (p Problem) Error() string {
+	if p.Detail != "" {
+		return fmt.Sprintf("%d %s: %s", p.Status, p.Title, p.Detail)
+	}
+	return fmt.Sprintf("%d %s", p.Status, p.Title)
+}
+
+// WriteProblem writes the problem with the `application/problem+json` content type.
+// The request ID set by the RequestID middleware is added as the `requestId` extension.
+func WriteProblem(w http.ResponseWriter, req *http.Request, p Problem) error {
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Instance == "" && req != nil && req.URL != nil {
+		p.Instance = req.URL.Path
+	}
+	if req != nil {
+		if id := limi.GetRequestID(req.Context()); id != "" {
+			if _, ok := p.Extensions[problemRequestID]; !ok {
+				ext := make(map[string]any, len(p.Extensions)+1)
+				for k, v := range p.Extensions {
+					ext[k] = v
+				}
+				ext[problemRequestID] = id
+				p.Extensions = ext
+			}
+		}
+	}
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return fmt.Errorf("error encoding problem %w", err)
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	if req != nil && req.Method == http.MethodHead {
+		return nil
+	}
+	if _, err := w.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("error writing problem %w", err)
+	}
+	return nil
+}
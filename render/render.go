@@ -0,0 +1,176 @@
+// Package render encodes responses with the encoder negotiated from the request's Accept header.
+package render
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/sanekee/limi/internal/limi"
+)
+
+var (
+	// ErrNotAcceptable is returned when no encoder is acceptable to the request.
+	ErrNotAcceptable = errors.New("not acceptable")
+	// ErrUnsupportedValue is returned by encoders when the value can't be encoded.
+	ErrUnsupportedValue = errors.New("unsupported value")
+)
+
+// Renderer renders responses with a list of encoders.
+// Encoders are negotiated with the request's Accept header quality values,
+// encoders with the same quality are preferred in the order they were registered.
+type Renderer struct {
+	mu       sync.RWMutex
+	encoders []Encoder
+}
+
+// New returns a new Renderer with a list of encoders.
+func New(encoders ...Encoder) *Renderer {
+	return &Renderer{
+		encoders: encoders,
+	}
+}
+
+// Default is the default Renderer with JSON, XML, CSV and plain text encoders.
+var Default = New(JSON(), XML(), CSV(), Text())
+
+// Register registers an encoder to the default Renderer.
+func Register(enc Encoder) {
+	Default.Register(enc)
+}
+
+// Respond renders v with the default Renderer, see Renderer.Respond.
+func Respond(w http.ResponseWriter, req *http.Request, status int, v any) error {
+	return Default.Respond(w, req, status, v)
+}
+
+// Register registers an encoder, an existing encoder with the same media type is replaced.
+func (r *Renderer) Register(enc Encoder) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	mt := mediaType(enc)
+	for i, e := range r.encoders {
+		if mediaType(e) == mt {
+			r.encoders[i] = enc
+			return
+		}
+	}
+	r.encoders = append(r.encoders, enc)
+}
+
+// Negotiate returns the list of encoders acceptable to the request, ordered by preference.
+// All encoders are acceptable when the request has no Accept header.
+func (r *Renderer) Negotiate(req *http.Request) []Encoder {
+	r.mu.RLock()
+	encoders := append([]Encoder{}, r.encoders...)
+	r.mu.RUnlock()
+
+	accept := strings.Join(req.Header.Values("Accept"), ",")
+	if accept == "" {
+		return encoders
+	}
+
+	ranges := limi.ParseAccept(accept)
+
+	type candidate struct {
+		enc Encoder
+		q   float64
+	}
+	var candidates []candidate
+	for _, enc := range encoders {
+		mt, ok := limi.ParseMediaRange(enc.ContentType())
+		if !ok {
+			continue
+		}
+		// charset is not part of the negotiated media type
+		delete(mt.Params, "charset")
+
+		if q := limi.AcceptQuality(ranges, mt); q > 0 {
+			candidates = append(candidates, candidate{enc: enc, q: q})
+		}
+	}
+
+	// stable insertion sort by quality, keeping the registration order
+	for i := 1; i < len(candidates); i++ {
+		for j := i; j > 0 && candidates[j].q > candidates[j-1].q; j-- {
+			candidates[j], candidates[j-1] = candidates[j-1], candidates[j]
+		}
+	}
+
+	ret := make([]Encoder, 0, len(candidates))
+	for _, c := range candidates {
+		ret = append(ret, c.enc)
+	}
+	return ret
+}
+
+// Respond writes v with status, encoded by the most preferred encoder able to encode v.
+//   - `Content-Type` is set to the encoder's content type, and `Vary: Accept` is added.
+//   - Responds with 406 Not Acceptable and returns ErrNotAcceptable when no acceptable encoder can encode v.
+//   - Responds with 500 Internal Server Error when encoding failed.
+//   - No body is written for status 204 No Content, 304 Not Modified and HEAD requests.
+func (r *Renderer) Respond(w http.ResponseWriter, req *http.Request, status int, v any) error {
+	addVary(w.Header(), "Accept")
+
+	if !bodyAllowed(status) {
+		w.WriteHeader(status)
+		return nil
+	}
+
+	for _, enc := range r.Negotiate(req) {
+		var buf bytes.Buffer
+		err := enc.Encode(&buf, v)
+		if errors.Is(err, ErrUnsupportedValue) {
+			continue
+		}
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return fmt.Errorf("error encoding %s %w", enc.ContentType(), err)
+		}
+
+		w.Header().Set("Content-Type", enc.ContentType())
+		w.WriteHeader(status)
+		if req.Method == http.MethodHead {
+			return nil
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return fmt.Errorf("error writing response %w", err)
+		}
+		return nil
+	}
+
+	http.Error(w, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+	return ErrNotAcceptable
+}
+
+// bodyAllowed returns false when the response with status must not have a body.
+func bodyAllowed(status int) bool {
+	return status != http.StatusNoContent &&
+		status != http.StatusNotModified &&
+		(status < 100 || status > 199)
+}
+
+// addVary adds value to the Vary header when it doesn't exist.
+func addVary(h http.Header, value string) {
+	for _, v := range h.Values("Vary") {
+		for _, s := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(s), value) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", value)
+}
+
+// mediaType returns the media type of the encoder without parameters.
+func mediaType(enc Encoder) string {
+	mt, ok := limi.ParseMediaRange(enc.ContentType())
+	if !ok {
+		return enc.ContentType()
+	}
+	return mt.String()
+}
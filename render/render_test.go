@@ -0,0 +1,184 @@
+package render
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sanekee/limi/internal/limi"
+	"github.com/sanekee/limi/internal/testing/require"
+)
+
+type testItem struct {
+	ID   int    `json:"id" xml:"id" csv:"id"`
+	Name string `json:"name" xml:"name" csv:"name"`
+	Note string `json:"-" xml:"-" csv:"-"`
+}
+
+func TestRespond(t *testing.T) {
+	items := []testItem{{ID: 1, Name: "foo"}, {ID: 2, Name: "bar"}}
+
+	type test struct {
+		testName    string
+		method      string
+		accept      string
+		status      int
+		v           any
+		expStatus   int
+		contentType string
+		body        string
+		err         error
+	}
+
+	tests := []test{
+		{testName: "no accept", accept: "", status: http.StatusOK, v: items, expStatus: http.StatusOK, contentType: "application/json; charset=utf-8", body: `[{"id":1,"name":"foo"},{"id":2,"name":"bar"}]` + "\n"},
+		{testName: "json", accept: "application/json", status: http.StatusCreated, v: items[0], expStatus: http.StatusCreated, contentType: "application/json; charset=utf-8", body: `{"id":1,"name":"foo"}` + "\n"},
+		{testName: "xml", accept: "application/xml", status: http.StatusOK, v: items[0], expStatus: http.StatusOK, contentType: "application/xml; charset=utf-8", body: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<testItem><id>1</id><name>foo</name></testItem>`},
+		{testName: "csv", accept: "text/csv", status: http.StatusOK, v: items, expStatus: http.StatusOK, contentType: "text/csv; charset=utf-8", body: "id,name\n1,foo\n2,bar\n"},
+		{testName: "text", accept: "text/plain", status: http.StatusOK, v: "hello", expStatus: http.StatusOK, contentType: "text/plain; charset=utf-8", body: "hello"},
+		{testName: "q values", accept: "application/json;q=0.5, text/csv", status: http.StatusOK, v: items, expStatus: http.StatusOK, contentType: "text/csv; charset=utf-8", body: "id,name\n1,foo\n2,bar\n"},
+		{testName: "wildcard", accept: "text/*", status: http.StatusOK, v: "hello", expStatus: http.StatusOK, contentType: "text/plain; charset=utf-8", body: "hello"},
+		{testName: "unsupported value fallback", accept: "text/csv, application/json;q=0.1", status: http.StatusOK, v: map[string]int{"a": 1}, expStatus: http.StatusOK, contentType: "application/json; charset=utf-8", body: `{"a":1}` + "\n"},
+		{testName: "not acceptable", accept: "image/png", status: http.StatusOK, v: items, expStatus: http.StatusNotAcceptable, err: ErrNotAcceptable},
+		{testName: "excluded", accept: "application/json;q=0, */*", status: http.StatusOK, v: "hello", expStatus: http.StatusOK, contentType: "application/xml; charset=utf-8", body: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<string>hello</string>`},
+		{testName: "no content", accept: "application/json", status: http.StatusNoContent, v: items, expStatus: http.StatusNoContent},
+		{testName: "head", method: http.MethodHead, accept: "application/json", status: http.StatusOK, v: items, expStatus: http.StatusOK, contentType: "application/json; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+
+			err := Respond(rec, req, tt.status, tt.v)
+			if tt.err != nil {
+				require.True(t, errors.Is(err, tt.err))
+			} else {
+				require.NoError(t, err)
+			}
+
+			res := rec.Result()
+			require.Equal(t, tt.expStatus, res.StatusCode)
+			require.Equal(t, "Accept", res.Header.Get("Vary"))
+			if tt.contentType != "" {
+				require.Equal(t, tt.contentType, res.Header.Get("Content-Type"))
+			}
+			if tt.body != "" || tt.expStatus == http.StatusNoContent || method == http.MethodHead {
+				body, err := io.ReadAll(res.Body)
+				require.NoError(t, err)
+				require.Equal(t, tt.body, string(body))
+			}
+		})
+	}
+}
+
+func TestRenderer(t *testing.T) {
+	t.Run("custom encoder", func(t *testing.T) {
+		rd := New(JSON())
+		rd.Register(NewEncoder("application/vnd.example+json", func(w io.Writer, v any) error {
+			_, err := io.WriteString(w, "custom")
+			return err
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", "application/vnd.example+json")
+		rec := httptest.NewRecorder()
+
+		err := rd.Respond(rec, req, http.StatusOK, "foo")
+		require.NoError(t, err)
+		require.Equal(t, "application/vnd.example+json", rec.Result().Header.Get("Content-Type"))
+		require.Equal(t, "custom", rec.Body.String())
+	})
+
+	t.Run("replace encoder", func(t *testing.T) {
+		rd := New(JSON(), Text())
+		rd.Register(NewEncoder("application/json", func(w io.Writer, v any) error {
+			_, err := io.WriteString(w, "replaced")
+			return err
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		encoders := rd.Negotiate(req)
+		require.Len(t, encoders, 2)
+		require.Equal(t, "application/json", encoders[0].ContentType())
+	})
+
+	t.Run("encoding error", func(t *testing.T) {
+		rd := New(JSON())
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+
+		err := rd.Respond(rec, req, http.StatusOK, func() {})
+		require.Error(t, err)
+		require.Equal(t, http.StatusInternalServerError, rec.Result().StatusCode)
+	})
+
+	t.Run("vary", func(t *testing.T) {
+		rd := New(JSON())
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Vary", "Accept-Encoding, accept")
+
+		err := rd.Respond(rec, req, http.StatusOK, "foo")
+		require.NoError(t, err)
+		require.Equal(t, []string{"Accept-Encoding, accept"}, rec.Result().Header.Values("Vary"))
+	})
+}
+
+func TestWriteProblem(t *testing.T) {
+	t.Run("problem", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/teams/1", nil)
+
+		require.NoError(t, WriteProblem(rec, req, NewProblem(http.StatusNotFound, "team 1 not found")))
+		require.Equal(t, http.StatusNotFound, rec.Code)
+		require.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+		require.Equal(t, `{"title":"Not Found","status":404,"detail":"team 1 not found","instance":"/teams/1"}`+"\n", rec.Body.String())
+	})
+
+	t.Run("extensions", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+		p := NewProblem(http.StatusBadRequest, "")
+		p.Extensions = map[string]any{"requestId": "abc", "status": 200}
+		require.NoError(t, WriteProblem(rec, req, p))
+		require.Equal(t, `{"instance":"/","requestId":"abc","status":400,"title":"Bad Request"}`+"\n", rec.Body.String())
+	})
+
+	t.Run("request id", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req = req.WithContext(limi.WithRequestID(req.Context(), "abc"))
+
+		require.NoError(t, WriteProblem(rec, req, NewProblem(http.StatusBadRequest, "")))
+		require.Equal(t, `{"instance":"/","requestId":"abc","status":400,"title":"Bad Request"}`+"\n", rec.Body.String())
+
+		rec = httptest.NewRecorder()
+		p := NewProblem(http.StatusBadRequest, "")
+		p.Extensions = map[string]any{"requestId": "def"}
+		require.NoError(t, WriteProblem(rec, req, p))
+		require.Equal(t, `{"instance":"/","requestId":"def","status":400,"title":"Bad Request"}`+"\n", rec.Body.String())
+		require.Equal(t, map[string]any{"requestId": "def"}, p.Extensions)
+	})
+
+	t.Run("head", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodHead, "/", nil)
+
+		require.NoError(t, WriteProblem(rec, req, Problem{Status: http.StatusTeapot}))
+		require.Equal(t, http.StatusTeapot, rec.Code)
+		require.Empty(t, rec.Body.String())
+	})
+}
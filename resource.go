@@ -0,0 +1,87 @@
+package limi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/sanekee/limi/internal/limi"
+)
+
+const defaultResourceID = "{id}"
+
+// resourceMethod is the http method and route of a resource handler's method.
+type resourceMethod struct {
+	method string
+	isItem bool
+}
+
+// resourceMethods maps the resource handler's methods to the collection (i.e. `/teams`) and item (i.e. `/teams/{id}`) routes.
+var resourceMethods = map[string]resourceMethod{
+	"List":   {method: http.MethodGet},
+	"Create": {method: http.MethodPost},
+	"Show":   {method: http.MethodGet, isItem: true},
+	"Update": {method: http.MethodPut, isItem: true},
+	"Patch":  {method: http.MethodPatch, isItem: true},
+	"Delete": {method: http.MethodDelete, isItem: true},
+}
+
+// WithResourceID set the default id pattern of the resource handlers' item route, default is `{id}`.
+// Subrouters inherit the parent's resource id pattern.
+func WithResourceID(pattern string) RouterOptions {
+	return func(r *Router) error {
+		if _, err := limi.SplitParsers(pattern); err != nil || pattern == "" {
+			return fmt.Errorf("invalid resource id pattern %s %w", pattern, limi.ErrInvalidInput)
+		}
+		r.resourceID = pattern
+		return nil
+	}
+}
+
+// isResourceHandler returns true when the handler is a resource handler,
+// i.e. it has the `resource` struct tag option, or one of the `List`, `Create`, `Show` and `Update` methods.
+func isResourceHandler(rt reflect.Type, tag handlerTag) bool {
+	if _, ok := tag[tagResource]; ok {
+		return true
+	}
+
+	for _, name := range []string{"List", "Create", "Show", "Update"} {
+		m, ok := rt.MethodByName(name)
+		if ok && (isHTTPHandlerMethod(m.Func) || isHTTPHandlerProducer(m.Func)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResourceID returns the id of the resource handler's item route, i.e. the value matched by `{id}` in `/teams/{id}`.
+func ResourceID(ctx context.Context) string {
+	return limi.GetURLParam(ctx, limi.GetResourceIDParam(ctx))
+}
+
+// resourceIDParam returns the url param name of the id pattern, i.e. `teamId` in `{teamId:[0-9]+}`.
+func resourceIDParam(pattern string) string {
+	parsers, err := limi.SplitParsers(pattern)
+	if err != nil {
+		return ""
+	}
+
+	var param string
+	for _, p := range parsers {
+		if p.Type == limi.TypeLabel || p.Type == limi.TypeRegexp {
+			param = limi.NewMatcher(p).Label()
+		}
+	}
+	return param
+}
+
+// setResourceIDParam returns a middleware setting the url param name of the resource id.
+func setResourceIDParam(param string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			limi.SetResourceIDParam(req.Context(), param)
+			next.ServeHTTP(w, req)
+		})
+	}
+}
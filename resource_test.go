@@ -0,0 +1,297 @@
+package limi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sanekee/limi/internal/testing/require"
+)
+
+type testTeams struct {
+	_ struct{} `limi:"path=/teams"`
+}
+
+func (testTeams) List(w http.ResponseWriter, req *http.Request) {
+	w.Write([]byte("list")) // nolint:errcheck
+}
+
+func (testTeams) Create(w http.ResponseWriter, req *http.Request) {
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte("create")) // nolint:errcheck
+}
+
+func (testTeams) Show(w http.ResponseWriter, req *http.Request) {
+	w.Write([]byte("show " + GetURLParam(req.Context(), "id"))) // nolint:errcheck
+}
+
+func (testTeams) Update(w http.ResponseWriter, req *http.Request) {
+	w.Write([]byte("update " + GetURLParam(req.Context(), "id"))) // nolint:errcheck
+}
+
+func (testTeams) Patch(w http.ResponseWriter, req *http.Request) {
+	w.Write([]byte("patch " + GetURLParam(req.Context(), "id"))) // nolint:errcheck
+}
+
+func (testTeams) Delete(w http.ResponseWriter, req *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type testTeamsID struct {
+	_ struct{} `limi:"path=/teams,id={teamId}"`
+}
+
+func (testTeamsID) Show(w http.ResponseWriter, req *http.Request) {
+	w.Write([]byte("show " + GetURLParam(req.Context(), "teamId"))) // nolint:errcheck
+}
+
+type testMerchants struct {
+	_ struct{} `limi:"path=/teams/{teamId}/merchants"`
+}
+
+func (testMerchants) List(w http.ResponseWriter, req *http.Request) {
+	w.Write([]byte("list " + GetURLParam(req.Context(), "teamId"))) // nolint:errcheck
+}
+
+func (testMerchants) Show(w http.ResponseWriter, req *http.Request) {
+	w.Write([]byte("show " + GetURLParam(req.Context(), "teamId") + " " + GetURLParam(req.Context(), "id"))) // nolint:errcheck
+}
+
+type testReports struct {
+	_ struct{} `limi:"path=/reports,resource"`
+}
+
+func (testReports) Get(w http.ResponseWriter, req *http.Request) {
+	w.Write([]byte("get")) // nolint:errcheck
+}
+
+func (testReports) Delete(w http.ResponseWriter, req *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type testMixedTeams struct {
+	_ struct{} `limi:"path=/teams"`
+}
+
+func (testMixedTeams) List(w http.ResponseWriter, req *http.Request) {
+	w.Write([]byte("list")) // nolint:errcheck
+}
+
+func (testMixedTeams) Options(w http.ResponseWriter, req *http.Request) {
+	w.Write([]byte("options")) // nolint:errcheck
+}
+
+type testConflictTeams struct {
+	_ struct{} `limi:"path=/teams"`
+}
+
+func (testConflictTeams) List(w http.ResponseWriter, req *http.Request) {}
+
+func (testConflictTeams) Get(w http.ResponseWriter, req *http.Request) {}
+
+type testReportsID struct {
+	id *string
+}
+
+func (t testReportsID) Delete(w http.ResponseWriter, req *http.Request) {
+	*t.id = ResourceID(req.Context())
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type testLayeredTeams struct {
+	_      struct{} `limi:"path=/teams,/groups"`
+	layers *[]string
+}
+
+func (t testLayeredTeams) ListMiddlewares() []func(http.Handler) http.Handler {
+	return []func(http.Handler) http.Handler{
+		func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				*t.layers = append(*t.layers, "list")
+				next.ServeHTTP(w, req)
+			})
+		},
+	}
+}
+
+func (t testLayeredTeams) List(w http.ResponseWriter, req *http.Request) {
+	w.Write([]byte("list")) // nolint:errcheck
+}
+
+func (t testLayeredTeams) Show(w http.ResponseWriter, req *http.Request) {
+	w.Write([]byte("show")) // nolint:errcheck
+}
+
+func TestResourceHandler(t *testing.T) {
+	serve := func(r http.Handler, method string, url string) (int, string) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, url, nil)
+		r.ServeHTTP(rec, req)
+
+		body, err := io.ReadAll(rec.Body)
+		require.NoError(t, err)
+		return rec.Result().StatusCode, string(body)
+	}
+
+	t.Run("collection and item", func(t *testing.T) {
+		r, err := NewRouter("/")
+		require.NoError(t, err)
+		require.NoError(t, r.AddHandler(testTeams{}))
+
+		type test struct {
+			method         string
+			url            string
+			expectedStatus int
+			expectedBody   string
+		}
+		tests := []test{
+			{method: http.MethodGet, url: "/teams", expectedStatus: http.StatusOK, expectedBody: "list"},
+			{method: http.MethodPost, url: "/teams", expectedStatus: http.StatusCreated, expectedBody: "create"},
+			{method: http.MethodGet, url: "/teams/1", expectedStatus: http.StatusOK, expectedBody: "show 1"},
+			{method: http.MethodPut, url: "/teams/1", expectedStatus: http.StatusOK, expectedBody: "update 1"},
+			{method: http.MethodPatch, url: "/teams/1", expectedStatus: http.StatusOK, expectedBody: "patch 1"},
+			{method: http.MethodDelete, url: "/teams/1", expectedStatus: http.StatusNoContent},
+			{method: http.MethodDelete, url: "/teams", expectedStatus: http.StatusMethodNotAllowed},
+			{method: http.MethodPost, url: "/teams/1", expectedStatus: http.StatusMethodNotAllowed},
+		}
+		for _, tt := range tests {
+			status, body := serve(r, tt.method, tt.url)
+			require.Equal(t, tt.expectedStatus, status)
+			if tt.expectedBody != "" {
+				require.Equal(t, tt.expectedBody, body)
+			}
+		}
+	})
+
+	t.Run("id tag", func(t *testing.T) {
+		r, err := NewRouter("/")
+		require.NoError(t, err)
+		require.NoError(t, r.AddHandler(testTeamsID{}))
+
+		status, body := serve(r, http.MethodGet, "/teams/12")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "show 12", body)
+	})
+
+	t.Run("WithResourceID", func(t *testing.T) {
+		r, err := NewRouter("/", WithResourceID("{id:[0-9]+}"))
+		require.NoError(t, err)
+
+		sr, err := r.AddRouter("/api")
+		require.NoError(t, err)
+		require.NoError(t, sr.AddHandler(testTeams{}))
+
+		status, body := serve(r, http.MethodGet, "/api/teams/12")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "show 12", body)
+
+		status, _ = serve(r, http.MethodGet, "/api/teams/abc")
+		require.Equal(t, http.StatusNotFound, status)
+
+		_, err = NewRouter("/", WithResourceID(""))
+		require.Error(t, err)
+	})
+
+	t.Run("ResourceID", func(t *testing.T) {
+		r, err := NewRouter("/")
+		require.NoError(t, err)
+
+		require.NoError(t, r.AddResource("/merchants", testMerchants{}))
+		require.NoError(t, r.AddResource("/teams", testTeamsID{}))
+
+		for _, pattern := range []string{"{id}", "{teamId:[0-9]+}", "t-{teamId}"} {
+			require.NotEmpty(t, resourceIDParam(pattern))
+		}
+
+		status, body := serve(r, http.MethodGet, "/teams/12")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "show 12", body)
+
+		rec := httptest.NewRecorder()
+		var id string
+		require.NoError(t, r.AddResource("/items", testReportsID{id: &id}))
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/items/abc", nil))
+		require.Equal(t, http.StatusNoContent, rec.Code)
+		require.Equal(t, "abc", id)
+	})
+
+	t.Run("nested resource", func(t *testing.T) {
+		r, err := NewRouter("/")
+		require.NoError(t, err)
+		require.NoError(t, r.AddHandler(testTeamsID{}))
+		require.NoError(t, r.AddHandler(testMerchants{}))
+
+		status, body := serve(r, http.MethodGet, "/teams/1/merchants")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "list 1", body)
+
+		status, body = serve(r, http.MethodGet, "/teams/1/merchants/2")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "show 1 2", body)
+
+		status, body = serve(r, http.MethodGet, "/teams/1")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "show 1", body)
+
+		status, _ = serve(r, http.MethodPost, "/teams/1/merchants/2")
+		require.Equal(t, http.StatusMethodNotAllowed, status)
+	})
+
+	t.Run("resource tag", func(t *testing.T) {
+		r, err := NewRouter("/")
+		require.NoError(t, err)
+		require.NoError(t, r.AddHandler(testReports{}))
+
+		status, _ := serve(r, http.MethodDelete, "/reports/1")
+		require.Equal(t, http.StatusNoContent, status)
+
+		// verb methods are added to the collection path
+		status, body := serve(r, http.MethodGet, "/reports")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "get", body)
+	})
+
+	t.Run("mixed methods", func(t *testing.T) {
+		r, err := NewRouter("/")
+		require.NoError(t, err)
+		require.NoError(t, r.AddHandler(testMixedTeams{}))
+
+		status, body := serve(r, http.MethodGet, "/teams")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "list", body)
+
+		status, body = serve(r, http.MethodOptions, "/teams")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "options", body)
+
+		err = r.AddHandler(testConflictTeams{})
+		require.Error(t, err)
+	})
+
+	t.Run("method middlewares with multiple paths", func(t *testing.T) {
+		var layers []string
+		r, err := NewRouter("/", WithMiddlewares(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				layers = append(layers, "router")
+				next.ServeHTTP(w, req)
+			})
+		}))
+		require.NoError(t, err)
+		require.NoError(t, r.AddHandler(testLayeredTeams{layers: &layers}))
+
+		for _, url := range []string{"/teams", "/groups"} {
+			layers = nil
+			status, body := serve(r, http.MethodGet, url)
+			require.Equal(t, http.StatusOK, status)
+			require.Equal(t, "list", body)
+			require.Equal(t, []string{"router", "list"}, layers)
+		}
+
+		layers = nil
+		status, body := serve(r, http.MethodGet, "/groups/1")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "show", body)
+		require.Equal(t, []string{"router"}, layers)
+	})
+}
@@ -0,0 +1,41 @@
+package limi
+
+import (
+	"net/http"
+
+	"github.com/sanekee/limi/internal/limi"
+	"github.com/sanekee/limi/render"
+)
+
+// WithRenderer set the Renderer used by Respond in the router's handlers, subrouters inherit the parent's renderer.
+// The default is render.Default.
+func WithRenderer(rd *render.Renderer) RouterOptions {
+	return func(r *Router) error {
+		r.renderer = rd
+		return nil
+	}
+}
+
+// Respond writes v with status, encoded by the encoder negotiated from the request's Accept header.
+// The router's renderer is used when set with WithRenderer, otherwise render.Default, see render.Renderer.Respond.
+func Respond(w http.ResponseWriter, req *http.Request, status int, v any) error {
+	return requestRenderer(req).Respond(w, req, status, v)
+}
+
+// requestRenderer returns the renderer of the matched router.
+func requestRenderer(req *http.Request) *render.Renderer {
+	if rd, ok := limi.GetRenderer(req.Context()).(*render.Renderer); ok && rd != nil {
+		return rd
+	}
+	return render.Default
+}
+
+// setRenderer returns a middleware setting the renderer in context.
+func setRenderer(rd *render.Renderer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			limi.SetRenderer(req.Context(), rd)
+			next.ServeHTTP(w, req)
+		})
+	}
+}
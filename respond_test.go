@@ -0,0 +1,70 @@
+package limi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sanekee/limi/internal/testing/require"
+	"github.com/sanekee/limi/render"
+)
+
+func TestRespond(t *testing.T) {
+	respond := func(w http.ResponseWriter, req *http.Request) {
+		Respond(w, req, http.StatusOK, map[string]string{"foo": "bar"}) // nolint:errcheck
+	}
+
+	t.Run("default renderer", func(t *testing.T) {
+		r, err := NewRouter("/")
+		require.NoError(t, err)
+
+		err = r.Get("/foo", respond)
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+		req.Header.Set("Accept", "application/json")
+
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+		require.Equal(t, "application/json; charset=utf-8", rec.Result().Header.Get("Content-Type"))
+		require.Equal(t, `{"foo":"bar"}`+"\n", rec.Body.String())
+	})
+
+	t.Run("router renderer", func(t *testing.T) {
+		rd := render.New(render.NewEncoder("application/vnd.example+json", func(w io.Writer, v any) error {
+			_, err := io.WriteString(w, "custom")
+			return err
+		}))
+
+		r, err := NewRouter("/", WithRenderer(rd))
+		require.NoError(t, err)
+
+		err = r.Get("/foo", respond)
+		require.NoError(t, err)
+
+		sr, err := r.AddRouter("/sub")
+		require.NoError(t, err)
+
+		err = sr.AddHTTPHandler("/bar", http.HandlerFunc(respond))
+		require.NoError(t, err)
+
+		for _, path := range []string{"/foo", "/sub/bar"} {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+
+			r.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+			require.Equal(t, "application/vnd.example+json", rec.Result().Header.Get("Content-Type"))
+			require.Equal(t, "custom", rec.Body.String())
+		}
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+		req.Header.Set("Accept", "application/json")
+
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusNotAcceptable, rec.Result().StatusCode)
+	})
+}
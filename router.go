@@ -9,11 +9,13 @@ import (
 	"strings"
 
 	"github.com/sanekee/limi/internal/limi"
+	"github.com/sanekee/limi/render"
 )
 
 const (
-	defaultHandlerPath     = "handler"
-	defaultIndexStructName = "index"
+	defaultHandlerPath       = "handler"
+	defaultIndexStructName   = "index"
+	handlerMiddlewaresMethod = "Middlewares"
 )
 
 type Handler any
@@ -41,14 +43,28 @@ type Handler any
 // "/blog/{id:[0-9]+}" ..      // matches paths /blog/1, /blog/2 ..., sets URLParams["id"] = <value>
 // "/blog/{slug}" ..           // matches paths /blog/cool-article-1, /blog/cool-article-2 ..., sets URLParam["slug"] = <value>
 type Router struct {
-	path        string
-	handlerPath string
-	host        *limi.Node
-	node        *limi.Node
+	path         string
+	handlerPath  string
+	handlerRoots []handlerRoot
+	strictMounts bool
+	naming       NamingStrategy
+	host         *limi.Node
+	hostPort     *limi.Node
+	node         *limi.Node
+
+	hostPatterns     []string
+	hostPortPatterns []string
+
 	middlewares []func(http.Handler) http.Handler
 
 	notFoundHandler         http.Handler
 	methodNotAllowedHandler func(...string) http.Handler
+	internalErrorHandler    http.Handler
+	customMethods           map[string]struct{}
+	pathPolicy              PathPolicy
+	versioning              *Versioning
+	renderer                *render.Renderer
+	resourceID              string
 
 	isSubRoute bool
 }
@@ -61,34 +77,41 @@ func NewRouter(path string, opts ...RouterOptions) (*Router, error) {
 	}
 
 	r.notFoundHandler = attachMiddlewares(r.notFoundHandler, r.middlewares...)
-
-	h := r.methodNotAllowedHandler
-	r.methodNotAllowedHandler = func(allowedMethods ...string) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-			handler := h(allowedMethods...)
-			handler = attachMiddlewares(handler, r.middlewares...)
-			handler.ServeHTTP(w, req)
-		})
-	}
 	return r, nil
 }
 
 type RouterOptions func(r *Router) error
 
 // WithHosts set Router's hosts matcher.
+// Subrouter's hosts are matched against the request host after the parent's path is matched.
+// Hosts are matched case insensitively, internationalized hosts are matched in punycode.
+// Host with a port (i.e. `example.com:8080`, `{sub}.example.com:{port}`, `[::1]:8080`) is matched against the request host and port.
 func WithHosts(hosts ...string) RouterOptions {
 	return func(r *Router) error {
-		if r.isSubRoute {
-			return fmt.Errorf("setting subroute with host is unsupported %w", limi.ErrUnsupportedOperation)
-		}
-		if r.host == nil {
-			n := &limi.Node{}
-			r.host = n
-		}
 		for _, h := range hosts {
-			if err := r.host.Insert(h, hostHandler{}); err != nil {
+			pattern, hasPort, err := normalizeHostPattern(h)
+			if err != nil {
+				return fmt.Errorf("invalid host %s %w", h, err)
+			}
+
+			if hasPort {
+				if r.hostPort == nil {
+					r.hostPort = &limi.Node{}
+				}
+				if err := r.hostPort.Insert(hostKey(pattern), hostHandler{}); err != nil {
+					return err
+				}
+				r.hostPortPatterns = append(r.hostPortPatterns, pattern)
+				continue
+			}
+
+			if r.host == nil {
+				r.host = &limi.Node{}
+			}
+			if err := r.host.Insert(hostKey(pattern), hostHandler{}); err != nil {
 				return err
 			}
+			r.hostPatterns = append(r.hostPatterns, pattern)
 		}
 		return nil
 	}
@@ -103,16 +126,43 @@ func WithMiddlewares(mws ...func(http.Handler) http.Handler) RouterOptions {
 }
 
 // WithNotFoundHandler set the not found handler.
+// Subrouter without a not found handler uses the parent's not found handler.
 func WithNotFoundHandler(h http.Handler) RouterOptions {
 	return func(r *Router) error {
-		if r.isSubRoute {
-			return fmt.Errorf("setting subroute with not found handler is not supported %w", limi.ErrUnsupportedOperation)
-		}
 		r.notFoundHandler = h
 		return nil
 	}
 }
 
+// WithInternalErrorHandler set the internal error handler, used by middlewares responding with 500 Internal Server Error (i.e. middleware.Recover).
+// Subrouters inherit the parent's internal error handler.
+func WithInternalErrorHandler(h http.Handler) RouterOptions {
+	return func(r *Router) error {
+		r.internalErrorHandler = h
+		return nil
+	}
+}
+
+// setInternalErrorHandler returns a middleware setting the internal error handler in context.
+func setInternalErrorHandler(h http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			limi.SetInternalErrorHandler(req.Context(), h)
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// setRoutePattern returns a middleware setting the route pattern in context.
+func setRoutePattern(pattern string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			limi.SetRoutePattern(req.Context(), pattern)
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
 // WithMethodNotAllowedHandler set the method not allowed handler with list of allowed methods.
 func WithMethodNotAllowedHandler(h func(...string) http.Handler) RouterOptions {
 	return func(r *Router) error {
@@ -157,22 +207,33 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	}
 
 	var path string
+	host := req.Host
 	if !limi.IsContextSet(ctx) {
 		ctx = limi.NewContext(ctx)
 		req = req.WithContext(ctx)
 
-		if !r.IsSupportedHost(ctx, parseHost(req.Host)) {
+		if !r.IsSupportedHost(ctx, host) {
 			r.notFoundHandler.ServeHTTP(w, req)
+			return
 		}
 		path = req.URL.Path
 
 	} else {
 		path = limi.GetRoutingPath(ctx)
 	}
+	limi.SetNotFoundHandler(ctx, r.notFoundHandler)
+
+	h, trail, notFoundHandler, redirectPath := r.match(ctx, host, path)
+	if redirectPath != "" {
+		r.redirect(w, req, strings.TrimSuffix(req.URL.Path, path)+redirectPath)
+		return
+	}
 
-	h, trail := r.lookup(ctx, path)
 	if h == nil {
-		r.notFoundHandler.ServeHTTP(w, req)
+		if notFoundHandler == nil {
+			notFoundHandler = r.notFoundHandler
+		}
+		notFoundHandler.ServeHTTP(w, req)
 		return
 	}
 
@@ -193,7 +254,114 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 // - Routing path is automatically discovered based on relative path to the router's `HandlerPath`.
 // - Custom routing path (*absolute* or *relative*) can be set using a struct tag, e.g. `_ struct{} `limi:"path:/custom-path"` field in the Handler struct.
 // - Multiple paths can be added to handle multiple paths, e.g. `_ struct{} `limi:"path=/story/cool-path,/story/strange-path,/best-path"`.
+// - Route conditions can be set in the struct tag, e.g. `_ struct{} `limi:"path=/story,accept=application/json,scheme=https"`, see Condition.
+//
+// # Resource Handler
+//
+// Handler with the `List`, `Create`, `Show`, `Update`, `Patch` and `Delete` methods (or the `resource` struct tag option) is added as a resource,
+// mounted at the collection path (i.e. `/teams`) and the item path (i.e. `/teams/{id}`).
+// - `List` and `Create` are the `GET` and `POST` handlers of the collection path.
+// - `Show`, `Update`, `Patch` and `Delete` are the `GET`, `PUT`, `PATCH` and `DELETE` handlers of the item path.
+// - The item's id pattern can be set with a struct tag, e.g. `_ struct{} `limi:"path=/teams,id={teamId}"`, or WithResourceID.
+// - Other http methods (i.e. `Options`) are handlers of the collection path, an error is returned when they conflict with `List` or `Create`.
+//
+// # Handler Middlewares
+//
+// Handler can declare its own middlewares with optional methods returning `[]func(http.Handler) http.Handler`.
+// - `Middlewares()` - middlewares applied to all http methods of the handler.
+// - `<Method>Middlewares()` (i.e. `GetMiddlewares()`) - middlewares applied to the single http method.
+//
+// Middlewares are executed in the order of router's middlewares, mws, `Middlewares()` and `<Method>Middlewares()`.
 func (r *Router) AddHandler(handler Handler, mws ...func(http.Handler) http.Handler) error {
+	return r.addHandler(handler, nil, routeConfig{}, mws...)
+}
+
+// AddHandlers adds multiple handlers with a list of middlewares.
+func (r *Router) AddHandlers(handlers []Handler, mws ...func(http.Handler) http.Handler) error {
+	for _, h := range handlers {
+		if err := r.AddHandler(h, mws...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddResource adds a resource handler with the collection path and a list of middlewares,
+// the handler's item routes are added at path with the id pattern (i.e. `/teams/{id}`), see Resource Handler in AddHandler.
+func (r *Router) AddResource(path string, handler Handler, mws ...func(http.Handler) http.Handler) error {
+	return r.addHandler(handler, []string{path}, routeConfig{}, mws...)
+}
+
+// AddHandlerFunc adds http handler with path and method.
+// Standard methods are case insensitive, custom methods must be set with WithCustomMethods.
+func (r *Router) AddHandlerFunc(path string, method string, fn http.HandlerFunc, mws ...func(http.Handler) http.Handler) error {
+	return r.addHandlerFunc(path, []string{method}, fn, routeConfig{}, mws...)
+}
+
+// Methods adds http handler with path for a list of methods.
+func (r *Router) Methods(path string, methods []string, fn http.HandlerFunc, mws ...func(http.Handler) http.Handler) error {
+	return r.addHandlerFunc(path, methods, fn, routeConfig{}, mws...)
+}
+
+// Get adds http handler with path for GET method.
+func (r *Router) Get(path string, fn http.HandlerFunc, mws ...func(http.Handler) http.Handler) error {
+	return r.AddHandlerFunc(path, http.MethodGet, fn, mws...)
+}
+
+// Post adds http handler with path for POST method.
+func (r *Router) Post(path string, fn http.HandlerFunc, mws ...func(http.Handler) http.Handler) error {
+	return r.AddHandlerFunc(path, http.MethodPost, fn, mws...)
+}
+
+// Put adds http handler with path for PUT method.
+func (r *Router) Put(path string, fn http.HandlerFunc, mws ...func(http.Handler) http.Handler) error {
+	return r.AddHandlerFunc(path, http.MethodPut, fn, mws...)
+}
+
+// Patch adds http handler with path for PATCH method.
+func (r *Router) Patch(path string, fn http.HandlerFunc, mws ...func(http.Handler) http.Handler) error {
+	return r.AddHandlerFunc(path, http.MethodPatch, fn, mws...)
+}
+
+// Delete adds http handler with path for DELETE method.
+func (r *Router) Delete(path string, fn http.HandlerFunc, mws ...func(http.Handler) http.Handler) error {
+	return r.AddHandlerFunc(path, http.MethodDelete, fn, mws...)
+}
+
+// Any adds http handler with path for all standard methods and router's custom methods.
+func (r *Router) Any(path string, fn http.HandlerFunc, mws ...func(http.Handler) http.Handler) error {
+	return r.Methods(path, r.allMethods(), fn, mws...)
+}
+
+// AddHTTPHandler adds a catch all http handler with path.
+func (r *Router) AddHTTPHandler(path string, h http.Handler, mws ...func(http.Handler) http.Handler) error {
+	return r.addHTTPHandler(path, h, routeConfig{}, mws...)
+}
+
+// With returns a Group sharing the router's tree with a list of middlewares attached to the routes added through it.
+func (r *Router) With(mws ...func(http.Handler) http.Handler) *Group {
+	return newGroup(r).With(mws...)
+}
+
+// When returns a Group sharing the router's tree with a list of conditions attached to the routes added through it.
+func (r *Router) When(conds ...Condition) *Group {
+	return newGroup(r).When(conds...)
+}
+
+// Versions returns a Group sharing the router's tree with the API versions of the routes added through it, see Versioning.
+func (r *Router) Versions(versions ...string) *Group {
+	return newGroup(r).Versions(versions...)
+}
+
+// Group calls fn with a new Group sharing the router's tree, returning the error of fn.
+// Unlike the chi-style `func(*Group)`, fn returns an error as adding a route returns an error instead of panicking.
+func (r *Router) Group(fn func(g *Group) error) error {
+	return newGroup(r).Group(fn)
+}
+
+// addHandler adds handler with route config and a list of middlewares.
+// Handler is added as a resource at paths when paths is set, otherwise the paths are resolved from the handler.
+func (r *Router) addHandler(handler Handler, paths []string, cfg routeConfig, mws ...func(http.Handler) http.Handler) error {
 	rt := reflect.TypeOf(handler)
 	baseRT := rt
 	if rt.Kind() == reflect.Pointer {
@@ -205,81 +373,190 @@ func (r *Router) AddHandler(handler Handler, mws ...func(http.Handler) http.Hand
 		return fmt.Errorf("unsupported handler type %s %w", baseRT.Kind(), limi.ErrUnsupportedOperation)
 	}
 
-	methodNotAllowedHandler := func(allowedMethods ...string) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-			handler := r.methodNotAllowedHandler(allowedMethods...)
-			handler = attachMiddlewares(handler, mws...)
-			handler.ServeHTTP(w, req)
-		})
+	tag := getHandlerTag(baseRT)
+	conds := append(append([]Condition{}, cfg.conditions...), conditionsFromTag(tag)...)
+	if err := validateConditions(conds); err != nil {
+		return err
+	}
+
+	versions := cfg.versions
+	if tagVersions := versionsFromTag(tag); len(tagVersions) > 0 {
+		versions = tagVersions
 	}
 
+	metadata := cfg.metadata
+	tagMetadata, err := metadataFromTag(tag)
+	if err != nil {
+		return err
+	}
+	if len(tagMetadata) > 0 {
+		metadata = mergeMetadata(metadata, tagMetadata)
+	}
+
+	handlerMws := concatMiddlewares(mws, getHandlerMiddlewares(rt, rv, handlerMiddlewaresMethod))
+
+	methodNotAllowedHandler := r.notAllowedHandler(handlerMws...)
+
 	methods := httpMethodHandlers{
-		m:                       make(map[string]http.Handler),
+		m:                       make(map[string][]methodHandler),
 		methodNotAllowedHandler: methodNotAllowedHandler,
-		paramsType:              getParamsType(baseRT),
 	}
+	itemMethods := httpMethodHandlers{
+		m:                       make(map[string][]methodHandler),
+		methodNotAllowedHandler: methodNotAllowedHandler,
+	}
+	paramsType := getParamsType(baseRT)
+	isResource := len(paths) > 0 || isResourceHandler(rt, tag)
+
+	idPattern := r.resourceID
+	if v := tag[tagID]; len(v) > 0 && v[0] != "" {
+		idPattern = v[0]
+	}
+	idParam := resourceIDParam(idPattern)
 
 	for i := 0; i < rt.NumMethod(); i++ {
 		m := rt.Method(i)
-		lName := strings.ToUpper(m.Name)
+
+		var fn http.Handler
 		if isHTTPHandlerProducer(m.Func) {
 			vs := m.Func.Call([]reflect.Value{rv})
 			v := vs[0]
-			if v.Kind() == reflect.Func {
-				methods.m[lName] = attachMiddlewares(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-					v.Call([]reflect.Value{reflect.ValueOf(w), reflect.ValueOf(req)})
-				}), mws...)
+			if v.Kind() != reflect.Func {
+				continue
 			}
+			fn = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				v.Call([]reflect.Value{reflect.ValueOf(w), reflect.ValueOf(req)})
+			})
 		} else if isHTTPHandlerMethod(m.Func) {
-			methods.m[lName] = attachMiddlewares(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			fn = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 				m.Func.Call([]reflect.Value{rv, reflect.ValueOf(w), reflect.ValueOf(req)})
-			}), mws...)
+			})
+		} else {
+			continue
+		}
+
+		methodMws := concatMiddlewares(handlerMws, getHandlerMiddlewares(rt, rv, m.Name+handlerMiddlewaresMethod))
+		hdl := []methodHandler{{
+			handler:    attachMiddlewares(fn, methodMws...),
+			metadata:   metadata,
+			paramsType: paramsType,
+			conditions: conds,
+			versions:   versions,
+		}}
+
+		// verb methods of a resource handler are added to the collection path
+		method, target := strings.ToUpper(m.Name), methods.m
+		if rm, ok := resourceMethods[m.Name]; ok && isResource {
+			method = rm.method
+			if rm.isItem {
+				hdl[0].handler = setResourceIDParam(idParam)(hdl[0].handler)
+				target = itemMethods.m
+			}
 		}
+		if _, ok := target[method]; ok {
+			return fmt.Errorf("handler %s method %s conflicts with the resource method %s %w", baseRT.Name(), m.Name, method, limi.ErrInvalidInput)
+		}
+		target[method] = hdl
 	}
 
-	if len(methods.m) == 0 {
+	if len(methods.m) == 0 && len(itemMethods.m) == 0 {
 		return nil
 	}
 
-	for _, path := range resolvePaths(baseRT, r.handlerPath) {
-		if err := r.insertMethodHandler(path, methods); err != nil {
-			return fmt.Errorf("failed to insert methods handler with path %s %w", path, err)
+	if len(paths) == 0 {
+		if r.strictMounts && isPackagePath(baseRT) {
+			if _, _, ok := findPackageMount(r.handlerRoots, baseRT.PkgPath()); !ok {
+				return fmt.Errorf("handler %s package %s is not mounted %w", baseRT.Name(), baseRT.PkgPath(), limi.ErrInvalidInput)
+			}
+		}
+		paths = resolvePaths(baseRT, r.allHandlerRoots(), r.naming)
+	}
+
+	for _, path := range paths {
+		if len(methods.m) > 0 {
+			if err := r.insertMethodHandler(path, methods); err != nil {
+				return fmt.Errorf("failed to insert methods handler with path %s %w", path, err)
+			}
+		}
+
+		if len(itemMethods.m) > 0 {
+			itemPath := removeTraillingSlash(path) + ensureLeadingSlash(idPattern)
+			if err := r.insertMethodHandler(itemPath, itemMethods); err != nil {
+				return fmt.Errorf("failed to insert methods handler with path %s %w", itemPath, err)
+			}
 		}
 	}
 
 	return nil
 }
 
-// AddHandlers adds multiple handlers with a list of middlewares.
-func (r *Router) AddHandlers(handlers []Handler, mws ...func(http.Handler) http.Handler) error {
-	for _, h := range handlers {
-		if err := r.AddHandler(h, mws...); err != nil {
-			return err
-		}
+// addHandlerFunc adds http handler with path, list of methods and route config.
+func (r *Router) addHandlerFunc(path string, methods []string, fn http.HandlerFunc, cfg routeConfig, mws ...func(http.Handler) http.Handler) error {
+	methods, err := normalizeMethods(methods, r.customMethods)
+	if err != nil {
+		return err
 	}
-	return nil
+
+	if err := validateConditions(cfg.conditions); err != nil {
+		return err
+	}
+
+	h := httpMethodHandlers{
+		m:                       make(map[string][]methodHandler),
+		methodNotAllowedHandler: r.notAllowedHandler(),
+	}
+
+	hdl := attachMiddlewares(fn, mws...)
+	for _, m := range methods {
+		h.m[m] = []methodHandler{{
+			handler:    hdl,
+			metadata:   cfg.metadata,
+			conditions: cfg.conditions,
+			versions:   cfg.versions,
+		}}
+	}
+	return r.insertMethodHandler(path, h)
 }
 
-// AddHandlerFunc adds http handler with path and method.
-func (r *Router) AddHandlerFunc(path string, method string, fn http.HandlerFunc, mws ...func(http.Handler) http.Handler) error {
-	path = r.buildPath(path)
-	return r.insertMethodHandler(path, httpMethodHandlers{
-		m: map[string]http.Handler{
-			method: attachMiddlewares(fn, mws...),
-		},
-		methodNotAllowedHandler: r.methodNotAllowedHandler,
-	})
+// allMethods returns the list of standard methods and router's custom methods.
+func (r *Router) allMethods() []string {
+	methods := append([]string{}, standardMethods...)
+	for m := range r.customMethods {
+		methods = append(methods, m)
+	}
+	return methods
 }
 
-// AddHTTPHandler adds a catch all http handler with path.
-func (r *Router) AddHTTPHandler(path string, h http.Handler, mws ...func(http.Handler) http.Handler) error {
+// addHTTPHandler adds a catch all http handler with path and route config.
+func (r *Router) addHTTPHandler(path string, h http.Handler, cfg routeConfig, mws ...func(http.Handler) http.Handler) error {
+	if err := validateConditions(cfg.conditions); err != nil {
+		return err
+	}
+
 	path = r.buildPath(path)
-	middlewares := append(r.middlewares, mws...)
+	middlewares := concatMiddlewares(r.middlewares, mws)
 	h = attachMiddlewares(h, middlewares...)
+	if cfg.metadata != nil {
+		h = attachMiddlewares(h, setMetadata(cfg.metadata))
+	}
+	if len(cfg.conditions) > 0 {
+		h = attachMiddlewares(h, conditionsMiddleware(cfg.conditions, r.notFoundHandler))
+	}
+	if r.renderer != nil {
+		h = attachMiddlewares(h, setRenderer(r.renderer))
+	}
+	if r.internalErrorHandler != nil {
+		h = attachMiddlewares(h, setInternalErrorHandler(r.internalErrorHandler))
+	}
+	h = attachMiddlewares(h, setRoutePattern(path))
 	return r.node.Insert(path, limi.HTTPHandler(h.ServeHTTP))
 }
 
 // AddRouter adds a sub router.
+//
+// Subrouters added with the same path are matched in the order they were added,
+// a subrouter is skipped when its hosts doesn't match the request host or the path is not found in the subrouter.
+// When no subrouter handles the request, the not found handler of the first matched subrouter is used, falling back to the parent's not found handler.
 func (r *Router) AddRouter(path string, opts ...RouterOptions) (*Router, error) {
 	nr, err := newRouter(path)
 	if err != nil {
@@ -287,6 +564,18 @@ func (r *Router) AddRouter(path string, opts ...RouterOptions) (*Router, error)
 	}
 
 	nr.isSubRoute = true
+	nr.notFoundHandler = nil
+	nr.pathPolicy = r.pathPolicy
+	nr.versioning = r.versioning
+	nr.renderer = r.renderer
+	nr.resourceID = r.resourceID
+	nr.internalErrorHandler = r.internalErrorHandler
+	for m := range r.customMethods {
+		if nr.customMethods == nil {
+			nr.customMethods = make(map[string]struct{})
+		}
+		nr.customMethods[m] = struct{}{}
+	}
 
 	fn := WithMiddlewares(r.middlewares...)
 	if err := fn(nr); err != nil {
@@ -299,15 +588,8 @@ func (r *Router) AddRouter(path string, opts ...RouterOptions) (*Router, error)
 		}
 	}
 
-	nr.notFoundHandler = attachMiddlewares(nr.notFoundHandler, nr.middlewares...)
-
-	h := nr.methodNotAllowedHandler
-	nr.methodNotAllowedHandler = func(allowedMethods ...string) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-			handler := h(allowedMethods...)
-			handler = attachMiddlewares(handler, nr.middlewares...)
-			handler.ServeHTTP(w, req)
-		})
+	if nr.notFoundHandler != nil {
+		nr.notFoundHandler = attachMiddlewares(nr.notFoundHandler, nr.middlewares...)
 	}
 
 	if err := r.insertRouter(nr); err != nil {
@@ -317,14 +599,32 @@ func (r *Router) AddRouter(path string, opts ...RouterOptions) (*Router, error)
 	return nr, nil
 }
 
-// IsSupportedHost match host with supported host.
+// IsSupportedHost match host (i.e. `req.Host` with optional port) with supported host.
 func (r *Router) IsSupportedHost(ctx context.Context, host string) bool {
-	if r.host == nil {
+	if r.host == nil && r.hostPort == nil {
 		return true
 	}
 
-	h, _ := r.host.Lookup(ctx, host)
-	return h != nil
+	hostname, port := splitHostPort(host)
+	hostname = normalizeHost(hostname)
+
+	// lookup with a new context, host params are only set when host is matched
+	hCtx := limi.NewContext(context.Background())
+	if r.host != nil {
+		if h, _ := r.host.Lookup(hCtx, hostKey(hostname)); h != nil {
+			limi.SetHostParams(ctx, limi.GetURLParams(hCtx))
+			return true
+		}
+	}
+
+	if r.hostPort != nil && port != "" {
+		hCtx = limi.NewContext(context.Background())
+		if h, _ := r.hostPort.Lookup(hCtx, hostKey(joinHostPort(hostname, port))); h != nil {
+			limi.SetHostParams(ctx, limi.GetURLParams(hCtx))
+			return true
+		}
+	}
+	return false
 }
 
 // IsPartial implements Node Handle interface, returning true indicates partial match is return for futher matching.
@@ -347,6 +647,8 @@ func newRouter(path string, opts ...RouterOptions) (*Router, error) {
 	r := &Router{
 		path:                    path,
 		handlerPath:             defaultHandlerPath,
+		naming:                  Lowercase,
+		resourceID:              defaultResourceID,
 		node:                    &limi.Node{},
 		notFoundHandler:         http.NotFoundHandler(),
 		methodNotAllowedHandler: methodNotAllowedHandler,
@@ -363,40 +665,70 @@ func newRouter(path string, opts ...RouterOptions) (*Router, error) {
 func (r *Router) insertMethodHandler(path string, h httpMethodHandlers) error {
 	path = r.buildPath(path)
 	handlers := buildMethodsHandlers(h, r.middlewares...)
+	handlers.notFoundHandler = r.notFoundHandler
+	handlers.versioning = r.versioning
+	handlers.renderer = r.renderer
+	handlers.internalErrorHandler = r.internalErrorHandler
+	handlers.pattern = path
 
 	return r.node.Insert(path, handlers)
 }
 
 // insertRouter inserts new router.
 func (r *Router) insertRouter(r1 *Router) error {
-	return r.node.Insert(r.buildPath(r1.path), r1)
+	return r.node.Insert(r.buildPath(r1.path), &subRouters{routers: []*Router{r1}})
 }
 
-// lookup lookup for a Handle to path, with the remining unmatched string.
-func (r *Router) lookup(ctx context.Context, path string) (limi.Handle, string) {
-	router := r
-	findPath := path
-	for {
-		h, trail := router.node.Lookup(ctx, findPath)
+// lookup lookup for a Handle to host and path, with the remining unmatched string.
+// When Handle is not found, the not found handler of the matched subrouter is returned.
+func (r *Router) lookup(ctx context.Context, host string, path string) (limi.Handle, string, http.Handler) {
+	h, trail := r.nodeLookup(ctx, path)
 
+	srs, ok := h.(*subRouters)
+	if !ok {
 		// exact match
 		if h != nil && trail == "" {
-			return h, ""
+			return h, "", nil
 		}
 
 		// handle not found or (trail is not empty and no sub matchers)
 		if h == nil || !h.IsPartial() {
-			return nil, trail
+			return nil, trail, nil
 		}
 
-		hr, ok := h.(*Router)
-		if !ok {
-			// catchall handler
-			return h, trail
+		// catchall handler
+		return h, trail, nil
+	}
+
+	var notFoundHandler http.Handler
+	for _, sr := range srs.routers {
+		// params of a subrouter not handling the request are discarded
+		saved := limi.SaveParams(ctx)
+		if !sr.IsSupportedHost(ctx, host) {
+			continue
 		}
 
-		router, findPath = hr, trail
+		// the nearest not found handler is used by the routes of the subrouter
+		parentNotFound := limi.GetNotFoundHandler(ctx)
+		if sr.notFoundHandler != nil {
+			limi.SetNotFoundHandler(ctx, sr.notFoundHandler)
+		}
+
+		h, subTrail, subNotFoundHandler := sr.lookup(ctx, host, trail)
+		if h != nil {
+			return h, subTrail, nil
+		}
+		limi.RestoreParams(ctx, saved)
+		limi.SetNotFoundHandler(ctx, parentNotFound)
+
+		if notFoundHandler == nil {
+			notFoundHandler = subNotFoundHandler
+		}
+		if notFoundHandler == nil {
+			notFoundHandler = sr.notFoundHandler
+		}
 	}
+	return nil, trail, notFoundHandler
 }
 
 // buildPath return a subpath relative to the router's path.
@@ -407,12 +739,16 @@ func (r *Router) buildPath(path string) string {
 	return buildPath(r.path, path)
 }
 
-// buildMethodsHandlers returns the methods handlers map with middlewares attached.
+// buildMethodsHandlers returns a copy of the methods handlers map with middlewares attached.
 func buildMethodsHandlers(hms httpMethodHandlers, mws ...func(http.Handler) http.Handler) httpMethodHandlers {
 	handlers := hms
+	handlers.m = make(map[string][]methodHandler, len(hms.m))
 
-	for method, h := range hms.m {
-		handlers.m[method] = attachMiddlewares(h, mws...)
+	for method, hs := range hms.m {
+		for _, h := range hs {
+			h.handler = attachMiddlewares(h.handler, mws...)
+			handlers.m[method] = append(handlers.m[method], h)
+		}
 	}
 	return handlers
 }
@@ -430,6 +766,25 @@ func attachMiddlewares(h http.Handler, mws ...func(http.Handler) http.Handler) h
 	return h
 }
 
+// concatMiddlewares returns a new list of middlewares with mws1 appended to mws.
+func concatMiddlewares(mws []func(http.Handler) http.Handler, mws1 []func(http.Handler) http.Handler) []func(http.Handler) http.Handler {
+	ret := make([]func(http.Handler) http.Handler, 0, len(mws)+len(mws1))
+	ret = append(ret, mws...)
+	return append(ret, mws1...)
+}
+
+// getHandlerMiddlewares returns the middlewares declared by the handler's method name.
+func getHandlerMiddlewares(rt reflect.Type, rv reflect.Value, name string) []func(http.Handler) http.Handler {
+	m, ok := rt.MethodByName(name)
+	if !ok || !isMiddlewaresProducer(m.Func) {
+		return nil
+	}
+
+	vs := m.Func.Call([]reflect.Value{rv})
+	mws, _ := vs[0].Interface().([]func(http.Handler) http.Handler)
+	return mws
+}
+
 // buildPath returns a path with parent prefix.
 func buildPath(parent string, path string) string {
 	var p string
@@ -470,14 +825,41 @@ func removeLeadingSlash(path string) string {
 	return strings.TrimPrefix(path, "/")
 }
 
-// findHandlerPath returns a string found after the handlerPath
+// findHandlerPath returns a string found after the handlerPath segments in path, path is returned as is when handlerPath is not found.
 func findHandlerPath(handlerPath, path string) string {
-	arrPath := strings.SplitAfter(path, handlerPath)
-	if len(arrPath) == 1 {
+	idx := handlerPathIndex(handlerPath, path)
+	if idx < 0 {
 		return path
 	}
 
-	return strings.Join(arrPath[1:], "/")
+	return path[idx+len(handlerPath):]
+}
+
+// handlerPathIndex returns the index of the first handlerPath in path matching whole path segments, or -1 when not found.
+func handlerPathIndex(handlerPath, path string) int {
+	handlerPath = strings.Trim(handlerPath, "/")
+	if handlerPath == "" {
+		return -1
+	}
+
+	for start := 0; start < len(path); {
+		idx := strings.Index(path[start:], handlerPath)
+		if idx < 0 {
+			return -1
+		}
+		idx += start
+		end := idx + len(handlerPath)
+		if (idx == 0 || path[idx-1] == '/') && (end == len(path) || path[end] == '/') {
+			return idx
+		}
+		start = idx + 1
+	}
+	return -1
+}
+
+// allHandlerRoots returns the handler roots with the router's HandlerPath.
+func (r *Router) allHandlerRoots() []handlerRoot {
+	return append([]handlerRoot{{path: r.handlerPath}}, r.handlerRoots...)
 }
 
 // packageName returns the package name from the package path.
@@ -490,6 +872,16 @@ func packageName(path string) string {
 	return path[idx+1:]
 }
 
+// notAllowedHandler returns the method not allowed handler with the router's middlewares and mws attached,
+// the router's middlewares are executed before mws as on the matched routes.
+func (r *Router) notAllowedHandler(mws ...func(http.Handler) http.Handler) func(...string) http.Handler {
+	h := r.methodNotAllowedHandler
+	middlewares := concatMiddlewares(r.middlewares, mws)
+	return func(allowedMethods ...string) http.Handler {
+		return attachMiddlewares(h(allowedMethods...), middlewares...)
+	}
+}
+
 // methodNotAllowedHandler returns a default handler when method a not allow for a path.
 func methodNotAllowedHandler(allowedMethods ...string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
@@ -502,31 +894,22 @@ func methodNotAllowedHandler(allowedMethods ...string) http.Handler {
 
 // getPaths return multiple paths tag in str
 func getPaths(t reflectTyper) []string {
-	var limiTag string
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		if limiTag = field.Tag.Get("limi"); limiTag != "" {
-			break
-		}
-	}
-
-	if limiTag == "" {
-		return nil
-	}
+	return getHandlerTag(t)[tagPath]
+}
 
-	strs := strings.Split(limiTag, "=")
-	if len(strs) != 2 ||
-		strings.TrimSpace(strs[0]) != "path" {
-		return nil
+// isPackagePath returns true when the handler's paths are derived from the package path,
+// i.e. without path tag or with relative paths.
+func isPackagePath(t reflectTyper) bool {
+	paths := getPaths(t)
+	if len(paths) == 0 {
+		return true
 	}
-
-	pathStrs := limi.SplitEscape(strs[1], ',')
-	var paths []string
-	for _, p := range pathStrs {
-		paths = append(paths, strings.TrimSpace(p))
+	for _, path := range paths {
+		if !strings.HasPrefix(path, "/") {
+			return true
+		}
 	}
-
-	return paths
+	return false
 }
 
 type reflectTyper interface {
@@ -536,20 +919,20 @@ type reflectTyper interface {
 	NumField() int
 }
 
-// resolvePaths build paths from a struct type with PkgPath, struct name and struct tag
-func resolvePaths(t reflectTyper, handlerPath string) []string {
+// resolvePaths build paths from a struct type with PkgPath, struct name and struct tag,
+// the package path is resolved under the handler roots and the struct name is converted with the naming strategy.
+func resolvePaths(t reflectTyper, roots []handlerRoot, naming NamingStrategy) []string {
 	pkgPath := t.PkgPath()
 	structName := t.Name()
 
 	paths := getPaths(t)
-	structName = strings.ToLower(structName)
 	pkgName := packageName(pkgPath)
-	trimmedPkgPath := removeTraillingSlash(findHandlerPath(handlerPath, pkgPath))
+	trimmedPkgPath := removeTraillingSlash(findHandlerRoot(roots, pkgPath))
 	// no path tag found, default to pkgPath + structname
 	if len(paths) == 0 {
 		path := trimmedPkgPath
-		if pkgName != structName && structName != defaultIndexStructName {
-			path += ensureLeadingSlash(structName)
+		if lName := strings.ToLower(structName); pkgName != lName && lName != defaultIndexStructName {
+			path += ensureLeadingSlash(naming(structName))
 		}
 		paths = append(paths, path)
 	}
@@ -568,9 +951,22 @@ func resolvePaths(t reflectTyper, handlerPath string) []string {
 
 // Map of HTTP Handlers by Methods.
 type httpMethodHandlers struct {
-	m                       map[string]http.Handler
+	m                       map[string][]methodHandler
 	methodNotAllowedHandler func(...string) http.Handler
-	paramsType              reflect.Type
+	notFoundHandler         http.Handler
+	internalErrorHandler    http.Handler
+	versioning              *Versioning
+	renderer                *render.Renderer
+	pattern                 string
+}
+
+// methodHandler is a handler of a http method with the route's metadata, params type, conditions and versions.
+type methodHandler struct {
+	handler    http.Handler
+	metadata   Metadata
+	paramsType reflect.Type
+	conditions []Condition
+	versions   []string
 }
 
 // keys returns a list of methods supported by the handler.
@@ -595,36 +991,155 @@ func (h httpMethodHandlers) IsMethodAllowed(method string) bool {
 
 // ServeHTTP implements Node Handle interface, handles net/http server requests.
 func (h httpMethodHandlers) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	hdl, ok := h.m[req.Method]
+	hs, ok := h.m[req.Method]
 	if !ok {
-		h.methodNotAllowedHandler(h.keys()...).ServeHTTP(w, req)
+		keys := h.keys()
+		limi.SetAllowedMethods(req.Context(), keys)
+		h.methodNotAllowedHandler(keys...).ServeHTTP(w, req)
+		return
+	}
+	if req.Method == http.MethodOptions {
+		limi.SetAllowedMethods(req.Context(), h.keys())
+	}
+
+	version := h.versioning.requestedVersion(req)
+	hdl, version, status := selectMethodHandler(hs, req, version)
+	if hdl == nil {
+		conditionFailedHandler(req.Context(), status, h.notFoundHandler).ServeHTTP(w, req)
 		return
 	}
 
-	if h.paramsType != nil {
-		limi.SetParamsType(req.Context(), h.paramsType)
+	if h.versioning != nil {
+		limi.SetAPIVersion(req.Context(), version)
+		h.versioning.setHeaders(w, version)
+	}
+
+	if hdl.paramsType != nil {
+		limi.SetParamsType(req.Context(), hdl.paramsType)
+	}
+	if hdl.metadata != nil {
+		limi.SetRouteMetadata(req.Context(), hdl.metadata)
+	}
+	if h.renderer != nil {
+		limi.SetRenderer(req.Context(), h.renderer)
 	}
-	hdl.ServeHTTP(w, req)
+	if h.internalErrorHandler != nil {
+		limi.SetInternalErrorHandler(req.Context(), h.internalErrorHandler)
+	}
+	limi.SetRoutePattern(req.Context(), h.pattern)
+	hdl.handler.ServeHTTP(w, req)
 }
 
 // Merge implements Node Handle interface, merges the handler from h1.
-// Returns true when existing handler is not found.
-// Returns false when existing handler is found.
+// Returns true when the handlers are merged.
+// Returns false when a handler without conditions exists for the same method and version.
 func (h httpMethodHandlers) Merge(h1 limi.Handle) bool {
 	hMap, ok := h1.(httpMethodHandlers)
 	if !ok {
 		return false
 	}
 
-	for method, hdl := range hMap.m {
-		if _, ok := h.m[method]; ok {
-			return false
+	for method, hs := range hMap.m {
+		for _, hdl := range hs {
+			if hasConflictHandler(h.m[method], hdl) {
+				return false
+			}
+		}
+	}
+
+	for method, hs := range hMap.m {
+		for _, hdl := range hs {
+			h.m[method] = appendMethodHandler(h.m[method], hdl)
 		}
-		h.m[method] = hdl
 	}
 	return true
 }
 
+// selectMethodHandler returns the handler matching the request conditions with the nearest version to the requested version.
+// Handlers with conditions take precedence over handlers without conditions, versioned handlers take precedence over unversioned handlers.
+// When no handler is matched, returns the response status of the unmatched condition.
+// Statuses other than 404 Not Found take precedence, i.e. 406 Not Acceptable when only the Accept condition is not matched.
+func selectMethodHandler(hs []methodHandler, req *http.Request, requested string) (*methodHandler, string, int) {
+	status := http.StatusNotFound
+
+	var selected *methodHandler
+	var selectedVersion string
+	for i := range hs {
+		h := &hs[i]
+		ok, s := matchConditions(h.conditions, req)
+		if !ok {
+			if status == http.StatusNotFound {
+				status = s
+			}
+			continue
+		}
+
+		version := requested
+		if len(h.versions) > 0 {
+			if version, ok = selectVersion(h.versions, requested); !ok {
+				continue
+			}
+		}
+
+		if selected == nil || isPreferredHandler(h, version, selected, selectedVersion) {
+			selected = h
+			selectedVersion = version
+		}
+	}
+
+	if selected == nil {
+		return nil, "", status
+	}
+	return selected, selectedVersion, 0
+}
+
+// isPreferredHandler returns true when handler h with version is preferred over handler h1 with version1.
+func isPreferredHandler(h *methodHandler, version string, h1 *methodHandler, version1 string) bool {
+	if (len(h.conditions) > 0) != (len(h1.conditions) > 0) {
+		return len(h.conditions) > 0
+	}
+	if (len(h.versions) > 0) != (len(h1.versions) > 0) {
+		return len(h.versions) > 0
+	}
+	return len(h.versions) > 0 && compareVersions(version, version1) > 0
+}
+
+// hasConflictHandler returns true when a handler without conditions is found for the same versions as hdl.
+func hasConflictHandler(hs []methodHandler, hdl methodHandler) bool {
+	if len(hdl.conditions) > 0 {
+		return false
+	}
+
+	for _, h := range hs {
+		if len(h.conditions) > 0 {
+			continue
+		}
+		if len(h.versions) == 0 && len(hdl.versions) == 0 {
+			return true
+		}
+		if hasSharedVersion(h.versions, hdl.versions) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDefaultHandler returns true when the handler has no conditions and versions.
+func isDefaultHandler(h methodHandler) bool {
+	return len(h.conditions) == 0 && len(h.versions) == 0
+}
+
+// appendMethodHandler returns hs with hdl appended, handlers without conditions and versions are kept last.
+func appendMethodHandler(hs []methodHandler, hdl methodHandler) []methodHandler {
+	if isDefaultHandler(hdl) || len(hs) == 0 || !isDefaultHandler(hs[len(hs)-1]) {
+		return append(hs, hdl)
+	}
+
+	last := hs[len(hs)-1]
+	hs = append(hs[:len(hs)-1:len(hs)-1], hdl)
+	return append(hs, last)
+}
+
 // isHTTPHandlerProducer check if the function produces a http.HandlerFunc
 func isHTTPHandlerProducer(v reflect.Value) bool {
 	if v.Kind() != reflect.Func {
@@ -650,6 +1165,21 @@ func isHTTPHandlerProducer(v reflect.Value) bool {
 
 }
 
+// isMiddlewaresProducer check if the function produces a list of middlewares
+func isMiddlewaresProducer(v reflect.Value) bool {
+	if v.Kind() != reflect.Func {
+		return false
+	}
+
+	vt := v.Type()
+	if vt.NumIn() != 1 || vt.NumOut() != 1 {
+		return false
+	}
+
+	mt := reflect.TypeOf([]func(http.Handler) http.Handler{})
+	return vt.Out(0).AssignableTo(mt)
+}
+
 // isHTTPHandlerMethod check if the method is a http.HandlerFunc
 func isHTTPHandlerMethod(v reflect.Value) bool {
 	if v.Kind() != reflect.Func {
@@ -676,15 +1206,35 @@ func isHTTPHandlerMethod(v reflect.Value) bool {
 		i2.AssignableTo(c2)
 }
 
-// parseHost return the host part of the req.Host
-func parseHost(str string) string {
-	arr := strings.Split(str, ":")
+// subRouters is a node Handle of subrouters added with the same path.
+type subRouters struct {
+	routers []*Router
+}
 
-	if len(arr) == 0 {
-		return ""
+// IsPartial implements Node Handle interface, returning true indicates partial match is return for futher matching.
+func (s *subRouters) IsPartial() bool {
+	return true
+}
+
+// Merge implements Node Handle interface, appends the subrouters from h1.
+func (s *subRouters) Merge(h1 limi.Handle) bool {
+	srs, ok := h1.(*subRouters)
+	if !ok {
+		return false
 	}
 
-	return arr[0]
+	s.routers = append(s.routers, srs.routers...)
+	return true
+}
+
+// IsMethodAllowed implements Node Handle interface, returns true to allow all methods on a router Handle
+func (s *subRouters) IsMethodAllowed(string) bool {
+	return true
+}
+
+// ServeHTTP implements Node Handle interface, subrouters are handled by lookup.
+func (s *subRouters) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	http.NotFound(w, req)
 }
 
 // hostHandler is a node Handle to match router's host
@@ -730,7 +1280,8 @@ func getParamsType(t reflect.Type) reflect.Type {
 			}
 
 			if strings.Contains(limiTag, "param") ||
-				strings.Contains(limiTag, "query") {
+				strings.Contains(limiTag, "query") ||
+				strings.Contains(limiTag, "host") {
 				return field.Type
 			}
 		}
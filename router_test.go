@@ -162,7 +162,15 @@ func TestFindHandlerPath(t *testing.T) {
 		},
 		{
 			pkgPath:  "base/handler/handlerfoo/",
-			expected: "/handler/foo/",
+			expected: "/handlerfoo/",
+		},
+		{
+			pkgPath:  "base/myhandlerx/foo",
+			expected: "base/myhandlerx/foo",
+		},
+		{
+			pkgPath:  "base/handler",
+			expected: "",
 		},
 		{
 			pkgPath:  "/foo",
@@ -274,23 +282,6 @@ func (t testSt) Get(http.ResponseWriter, *http.Request) {}
 
 func (t testSt) Fetch() {}
 
-func TestParseHost(t *testing.T) {
-	t.Run("has port", func(t *testing.T) {
-		actual := parseHost("host:8080")
-		require.Equal(t, "host", actual)
-	})
-
-	t.Run("no colon", func(t *testing.T) {
-		actual := parseHost("host")
-		require.Equal(t, "host", actual)
-	})
-
-	t.Run("empty string", func(t *testing.T) {
-		actual := parseHost("")
-		require.Equal(t, "", actual)
-	})
-}
-
 func TestAddHandler(t *testing.T) {
 	t.Run("add with default package handler", func(t *testing.T) {
 		r, err := NewRouter("/")
@@ -721,6 +712,30 @@ func (t testHandlerWithParams) Get(w http.ResponseWriter, req *http.Request) {
 	t.get(w, req)
 }
 
+type testHandlerWithMiddlewares struct {
+	_      struct{} `limi:"path=/mws"`
+	mws    []func(http.Handler) http.Handler
+	getMws []func(http.Handler) http.Handler
+	get    http.HandlerFunc
+	post   http.HandlerFunc
+}
+
+func (t testHandlerWithMiddlewares) Middlewares() []func(http.Handler) http.Handler {
+	return t.mws
+}
+
+func (t testHandlerWithMiddlewares) GetMiddlewares() []func(http.Handler) http.Handler {
+	return t.getMws
+}
+
+func (t testHandlerWithMiddlewares) Get(w http.ResponseWriter, req *http.Request) {
+	t.get(w, req)
+}
+
+func (t testHandlerWithMiddlewares) Post(w http.ResponseWriter, req *http.Request) {
+	t.post(w, req)
+}
+
 func TestAddRouter(t *testing.T) {
 	t.Run("add sub route", func(t *testing.T) {
 		r, err := NewRouter("/")
@@ -910,7 +925,7 @@ func TestAddRouter(t *testing.T) {
 
 		err = r.AddHandlerFunc("/foo", http.MethodGet, func(w http.ResponseWriter, req *http.Request) {
 			w.WriteHeader(http.StatusOK)
-			hostname := parseHost(req.URL.Host)
+			hostname, _ := splitHostPort(req.URL.Host)
 			w.Write([]byte("foo" + ":" + hostname)) // nolint:errcheck
 		})
 		require.NoError(t, err)
@@ -945,7 +960,7 @@ func TestAddRouter(t *testing.T) {
 
 		err = r.AddHandlerFunc("/foo", http.MethodGet, func(w http.ResponseWriter, req *http.Request) {
 			w.WriteHeader(http.StatusOK)
-			hostname := parseHost(req.URL.Host)
+			hostname, _ := splitHostPort(req.URL.Host)
 			w.Write([]byte("foo" + ":" + hostname)) // nolint:errcheck
 		})
 		require.NoError(t, err)
@@ -997,6 +1012,138 @@ func TestAddRouter(t *testing.T) {
 		require.Equal(t, "foo", string(body))
 	})
 
+	t.Run("sub route with hosts", func(t *testing.T) {
+		r, err := NewRouter("/")
+		require.NoError(t, err)
+
+		r1, err := r.AddRouter("/api", WithHosts("tenant1.example.com"))
+		require.NoError(t, err)
+
+		err = r1.AddHandlerFunc("/foo", http.MethodGet, handler.NewHandlerFunc(http.StatusOK, nil, []byte("tenant1")))
+		require.NoError(t, err)
+
+		r2, err := r.AddRouter("/api", WithHosts("{tenant}.example.com"))
+		require.NoError(t, err)
+
+		err = r2.AddHandlerFunc("/foo", http.MethodGet, func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(GetURLParam(req.Context(), "tenant"))) // nolint:errcheck
+		})
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://tenant1.example.com/api/foo", nil)
+
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		body, err := io.ReadAll(rec.Body)
+		require.NoError(t, err)
+		require.Equal(t, "tenant1", string(body))
+
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "http://tenant2.example.com:8080/api/foo", nil)
+
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		body, err = io.ReadAll(rec.Body)
+		require.NoError(t, err)
+		require.Equal(t, "tenant2", string(body))
+
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "http://localhost/api/foo", nil)
+
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
+	})
+
+	t.Run("sub route with same path, fallback", func(t *testing.T) {
+		r, err := NewRouter("/")
+		require.NoError(t, err)
+
+		r1, err := r.AddRouter("/api")
+		require.NoError(t, err)
+
+		err = r1.AddHandlerFunc("/foo", http.MethodGet, handler.NewHandlerFunc(http.StatusOK, nil, []byte("foo")))
+		require.NoError(t, err)
+
+		r2, err := r.AddRouter("/api")
+		require.NoError(t, err)
+
+		err = r2.AddHandlerFunc("/bar", http.MethodGet, handler.NewHandlerFunc(http.StatusOK, nil, []byte("bar")))
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/api/bar", nil)
+
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		body, err := io.ReadAll(rec.Body)
+		require.NoError(t, err)
+		require.Equal(t, "bar", string(body))
+	})
+
+	t.Run("sub route not found handler", func(t *testing.T) {
+		r, err := NewRouter("/", WithNotFoundHandler(handler.NewHandler(http.StatusNotFound, nil, []byte("html"))))
+		require.NoError(t, err)
+
+		r1, err := r.AddRouter("/api", WithNotFoundHandler(handler.NewHandler(http.StatusNotFound, nil, []byte("json"))))
+		require.NoError(t, err)
+
+		r2, err := r1.AddRouter("/v1")
+		require.NoError(t, err)
+
+		err = r2.AddHandlerFunc("/foo", http.MethodGet, handler.NewHandlerFunc(http.StatusOK, nil, []byte("foo")))
+		require.NoError(t, err)
+
+		_, err = r.AddRouter("/admin", WithHosts("admin"), WithNotFoundHandler(handler.NewHandler(http.StatusNotFound, nil, []byte("admin"))))
+		require.NoError(t, err)
+
+		tests := []struct {
+			url      string
+			expected string
+		}{
+			{url: "http://localhost/foo", expected: "html"},
+			{url: "http://localhost/api/foo", expected: "json"},
+			{url: "http://localhost/api/v1/bar", expected: "json"},
+			{url: "http://localhost/admin/foo", expected: "html"},
+			{url: "http://admin/admin/foo", expected: "admin"},
+		}
+
+		for _, tt := range tests {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+
+			r.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
+
+			body, err := io.ReadAll(rec.Body)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, string(body))
+		}
+	})
+
+	t.Run("unsupported host", func(t *testing.T) {
+		var notFound int
+		r, err := NewRouter("/", WithHosts("abc"), WithNotFoundHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			notFound++
+			w.WriteHeader(http.StatusNotFound)
+		})))
+		require.NoError(t, err)
+
+		err = r.AddHandlerFunc("/foo", http.MethodGet, handler.NewHandlerFunc(http.StatusOK, nil, []byte("foo")))
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/foo", nil)
+
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
+		require.Equal(t, 1, notFound)
+	})
+
 	t.Run("add profiler", func(t *testing.T) {
 		r, err := NewRouter("/admin", WithProfiler())
 		require.NoError(t, err)
@@ -1202,6 +1349,157 @@ func TestAddHandlerFunc(t *testing.T) {
 		require.Equal(t, "foo", string(body))
 	})
 
+	t.Run("add func with router path", func(t *testing.T) {
+		r, err := NewRouter("/base")
+		require.NoError(t, err)
+
+		err = r.AddHandlerFunc("/foo", http.MethodGet, handler.NewHandlerFunc(http.StatusOK, nil, []byte("foo")))
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://localhost:9090/base/foo", nil)
+
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+	})
+
+	t.Run("normalize method", func(t *testing.T) {
+		r, err := NewRouter("/")
+		require.NoError(t, err)
+
+		err = r.AddHandlerFunc("/foo", "get", handler.NewHandlerFunc(http.StatusOK, nil, []byte("foo")))
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://localhost:9090/foo", nil)
+
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+	})
+
+	t.Run("invalid method", func(t *testing.T) {
+		r, err := NewRouter("/")
+		require.NoError(t, err)
+
+		err = r.AddHandlerFunc("/foo", "FETCH", handler.NewHandlerFunc(http.StatusOK, nil, []byte("foo")))
+		require.Error(t, err)
+
+		err = r.AddHandlerFunc("/foo", "GET POST", handler.NewHandlerFunc(http.StatusOK, nil, []byte("foo")))
+		require.Error(t, err)
+	})
+
+	t.Run("custom method", func(t *testing.T) {
+		r, err := NewRouter("/", WithCustomMethods("PURGE"))
+		require.NoError(t, err)
+
+		r1, err := r.AddRouter("/sub")
+		require.NoError(t, err)
+
+		err = r.AddHandlerFunc("/foo", "purge", handler.NewHandlerFunc(http.StatusOK, nil, []byte("purge")))
+		require.NoError(t, err)
+
+		err = r1.AddHandlerFunc("/foo", "PURGE", handler.NewHandlerFunc(http.StatusOK, nil, []byte("sub purge")))
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("PURGE", "http://localhost:9090/foo", nil)
+
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest("PURGE", "http://localhost:9090/sub/foo", nil)
+
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		body, err := io.ReadAll(rec.Body)
+		require.NoError(t, err)
+		require.Equal(t, "sub purge", string(body))
+	})
+
+	t.Run("lower case custom method", func(t *testing.T) {
+		r, err := NewRouter("/", WithCustomMethods("purge", "PropFind"))
+		require.NoError(t, err)
+
+		err = r.AddHandlerFunc("/foo", "PURGE", handler.NewHandlerFunc(http.StatusOK, nil, []byte("purge")))
+		require.NoError(t, err)
+		err = r.AddHandlerFunc("/foo", "propfind", handler.NewHandlerFunc(http.StatusOK, nil, []byte("propfind")))
+		require.NoError(t, err)
+
+		for _, m := range []string{"PURGE", "PROPFIND"} {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(m, "http://localhost:9090/foo", nil))
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.Equal(t, map[string]string{"PURGE": "purge", "PROPFIND": "propfind"}[m], rec.Body.String())
+		}
+	})
+
+	t.Run("multiple methods", func(t *testing.T) {
+		r, err := NewRouter("/")
+		require.NoError(t, err)
+
+		err = r.Methods("/foo", []string{http.MethodGet, http.MethodPost}, func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(req.Method + ":foo")) // nolint:errcheck
+		})
+		require.NoError(t, err)
+
+		for _, m := range []string{http.MethodGet, http.MethodPost} {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(m, "http://localhost:9090/foo", nil)
+
+			r.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+			body, err := io.ReadAll(rec.Body)
+			require.NoError(t, err)
+			require.Equal(t, m+":foo", string(body))
+		}
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, "http://localhost:9090/foo", nil)
+
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Result().StatusCode)
+	})
+
+	t.Run("verb helpers", func(t *testing.T) {
+		r, err := NewRouter("/")
+		require.NoError(t, err)
+
+		fn := func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(req.Method)) // nolint:errcheck
+		}
+		require.NoError(t, r.Get("/foo", fn))
+		require.NoError(t, r.Post("/foo", fn))
+		require.NoError(t, r.Put("/foo", fn))
+		require.NoError(t, r.Patch("/foo", fn))
+		require.NoError(t, r.Delete("/foo", fn))
+		require.NoError(t, r.Any("/bar", fn))
+
+		for _, m := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(m, "http://localhost:9090/foo", nil)
+
+			r.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+			body, err := io.ReadAll(rec.Body)
+			require.NoError(t, err)
+			require.Equal(t, m, string(body))
+		}
+
+		for _, m := range []string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace} {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(m, "http://localhost:9090/bar", nil)
+
+			r.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+		}
+	})
+
 	t.Run("add handler with params tag middleware", func(t *testing.T) {
 		r, err := NewRouter("/")
 		require.NoError(t, err)
@@ -1605,7 +1903,60 @@ func TestMiddleware(t *testing.T) {
 		r.ServeHTTP(rec, req)
 		require.Equal(t, http.StatusMethodNotAllowed, rec.Result().StatusCode)
 
-		require.Equal(t, []int{4, 1, 2, 3}, layers)
+		require.Equal(t, []int{1, 2, 4, 3}, layers)
+	})
+
+	t.Run("router, handler, handler middlewares, method middlewares", func(t *testing.T) {
+		var layers []int
+		r, err := NewRouter("/", WithMiddlewares(newMiddleware(&layers, 1)))
+		require.NoError(t, err)
+
+		h := testHandlerWithMiddlewares{
+			mws: []func(http.Handler) http.Handler{
+				newMiddleware(&layers, 3),
+			},
+			getMws: []func(http.Handler) http.Handler{
+				newMiddleware(&layers, 4),
+			},
+			get:  newHandler(&layers, 5, http.StatusOK, []byte("get")),
+			post: newHandler(&layers, 6, http.StatusOK, []byte("post")),
+		}
+		err = r.AddHandler(h, newMiddleware(&layers, 2))
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://localhost:9090/mws", nil)
+
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		body, err := io.ReadAll(rec.Body)
+		require.NoError(t, err)
+		require.Equal(t, "get", string(body))
+
+		require.Equal(t, []int{1, 2, 3, 4, 5}, layers)
+
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodPost, "http://localhost:9090/mws", nil)
+
+		layers = []int{}
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		body, err = io.ReadAll(rec.Body)
+		require.NoError(t, err)
+		require.Equal(t, "post", string(body))
+
+		require.Equal(t, []int{1, 2, 3, 6}, layers)
+
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodDelete, "http://localhost:9090/mws", nil)
+
+		layers = []int{}
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Result().StatusCode)
+
+		require.Equal(t, []int{1, 2, 3}, layers)
 	})
 
 	t.Run("flusher", func(t *testing.T) {
@@ -1786,7 +2137,7 @@ func TestResolvePaths(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.testName, func(t *testing.T) {
-			actual := resolvePaths(tt.handlerType, tt.handlerPath)
+			actual := resolvePaths(tt.handlerType, []handlerRoot{{path: tt.handlerPath}}, Lowercase)
 			require.Equal(t, tt.expected, actual)
 		})
 	}
@@ -0,0 +1,150 @@
+package limi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+)
+
+var (
+	// ErrNotFound is returned by a Store when the item doesn't exist, responded with 404 Not Found by Resource.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned by a Store when the item conflicts with an existing item, responded with 409 Conflict by Resource.
+	ErrConflict = errors.New("conflict")
+)
+
+// ListOptions is the filters and pagination of a Store's List.
+type ListOptions struct {
+	// Filters is the url queries filtering the items, i.e. `?name=foo`.
+	Filters url.Values
+	// Offset is the number of items skipped.
+	Offset int
+	// Limit is the maximum number of items returned.
+	Limit int
+}
+
+// Store is the storage of a Resource's items with id type ID.
+type Store[T any, ID comparable] interface {
+	// List returns a page of the items matching the filters, and the total number of items matching the filters.
+	List(ctx context.Context, opts ListOptions) ([]T, int, error)
+	// Get returns the item with id, returns ErrNotFound when the item doesn't exist.
+	Get(ctx context.Context, id ID) (T, error)
+	// Create creates the item, returns the item created with its id.
+	Create(ctx context.Context, item T) (T, error)
+	// Update replaces the item with id, returns ErrNotFound when the item doesn't exist.
+	Update(ctx context.Context, id ID, item T) (T, error)
+	// Delete deletes the item with id, returns ErrNotFound when the item doesn't exist.
+	Delete(ctx context.Context, id ID) error
+}
+
+// MemStore is an in memory Store, items are listed in the order they were created.
+type MemStore[T any, ID comparable] struct {
+	// Filter returns true when the item matches the filters, all items are matched when Filter is not set.
+	Filter func(item T, filters url.Values) bool
+
+	rwLock sync.RWMutex
+	items  map[ID]T
+	ids    []ID
+	newID  func() ID
+	setID  func(item T, id ID) T
+}
+
+// NewMemStore returns a MemStore generating ids of the created items with newID, and setting the id of the items with setID.
+// newID is called with the store locked.
+func NewMemStore[T any, ID comparable](newID func() ID, setID func(item T, id ID) T) *MemStore[T, ID] {
+	return &MemStore[T, ID]{
+		items: make(map[ID]T),
+		newID: newID,
+		setID: setID,
+	}
+}
+
+// List implements Store.
+func (m *MemStore[T, ID]) List(ctx context.Context, opts ListOptions) ([]T, int, error) {
+	m.rwLock.RLock()
+	defer m.rwLock.RUnlock()
+
+	var matched []T
+	for _, id := range m.ids {
+		item := m.items[id]
+		if m.Filter != nil && len(opts.Filters) > 0 && !m.Filter(item, opts.Filters) {
+			continue
+		}
+		matched = append(matched, item)
+	}
+
+	total := len(matched)
+	if opts.Offset >= total {
+		return []T{}, total, nil
+	}
+	matched = matched[opts.Offset:]
+	if opts.Limit > 0 && opts.Limit < len(matched) {
+		matched = matched[:opts.Limit]
+	}
+	return matched, total, nil
+}
+
+// Get implements Store.
+func (m *MemStore[T, ID]) Get(ctx context.Context, id ID) (T, error) {
+	m.rwLock.RLock()
+	defer m.rwLock.RUnlock()
+
+	item, ok := m.items[id]
+	if !ok {
+		return item, fmt.Errorf("item %v %w", id, ErrNotFound)
+	}
+	return item, nil
+}
+
+// Create implements Store.
+func (m *MemStore[T, ID]) Create(ctx context.Context, item T) (T, error) {
+	m.rwLock.Lock()
+	defer m.rwLock.Unlock()
+
+	id := m.newID()
+	if _, ok := m.items[id]; ok {
+		var zero T
+		return zero, fmt.Errorf("item %v %w", id, ErrConflict)
+	}
+
+	item = m.setID(item, id)
+	m.items[id] = item
+	m.ids = append(m.ids, id)
+	return item, nil
+}
+
+// Update implements Store.
+func (m *MemStore[T, ID]) Update(ctx context.Context, id ID, item T) (T, error) {
+	m.rwLock.Lock()
+	defer m.rwLock.Unlock()
+
+	if _, ok := m.items[id]; !ok {
+		var zero T
+		return zero, fmt.Errorf("item %v %w", id, ErrNotFound)
+	}
+
+	item = m.setID(item, id)
+	m.items[id] = item
+	return item, nil
+}
+
+// Delete implements Store.
+func (m *MemStore[T, ID]) Delete(ctx context.Context, id ID) error {
+	m.rwLock.Lock()
+	defer m.rwLock.Unlock()
+
+	if _, ok := m.items[id]; !ok {
+		return fmt.Errorf("item %v %w", id, ErrNotFound)
+	}
+
+	delete(m.items, id)
+	for i, v := range m.ids {
+		if v == id {
+			m.ids = append(m.ids[:i], m.ids[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package limi
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/sanekee/limi/internal/testing/require"
+)
+
+func TestMemStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("crud", func(t *testing.T) {
+		store := newTestTeamStore()
+
+		team, err := store.Create(ctx, testTeam{Name: "foo"})
+		require.NoError(t, err)
+		require.Equal(t, testTeam{ID: 1, Name: "foo"}, team)
+
+		team, err = store.Get(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, testTeam{ID: 1, Name: "foo"}, team)
+
+		team, err = store.Update(ctx, 1, testTeam{Name: "bar"})
+		require.NoError(t, err)
+		require.Equal(t, testTeam{ID: 1, Name: "bar"}, team)
+
+		require.NoError(t, store.Delete(ctx, 1))
+
+		_, err = store.Get(ctx, 1)
+		require.True(t, errors.Is(err, ErrNotFound))
+
+		_, err = store.Update(ctx, 1, testTeam{Name: "bar"})
+		require.True(t, errors.Is(err, ErrNotFound))
+
+		require.True(t, errors.Is(store.Delete(ctx, 1), ErrNotFound))
+	})
+
+	t.Run("list", func(t *testing.T) {
+		store := newTestTeamStore()
+		for _, name := range []string{"foo", "bar", "foo"} {
+			_, err := store.Create(ctx, testTeam{Name: name})
+			require.NoError(t, err)
+		}
+		require.NoError(t, store.Delete(ctx, 2))
+
+		teams, total, err := store.List(ctx, ListOptions{})
+		require.NoError(t, err)
+		require.Equal(t, 2, total)
+		require.Equal(t, []testTeam{{ID: 1, Name: "foo"}, {ID: 3, Name: "foo"}}, teams)
+
+		teams, total, err = store.List(ctx, ListOptions{Offset: 1, Limit: 1})
+		require.NoError(t, err)
+		require.Equal(t, 2, total)
+		require.Equal(t, []testTeam{{ID: 3, Name: "foo"}}, teams)
+
+		teams, total, err = store.List(ctx, ListOptions{Offset: 5})
+		require.NoError(t, err)
+		require.Equal(t, 2, total)
+		require.Empty(t, teams)
+
+		teams, total, err = store.List(ctx, ListOptions{Filters: url.Values{"name": {"bar"}}})
+		require.NoError(t, err)
+		require.Equal(t, 0, total)
+		require.Empty(t, teams)
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		store := NewMemStore(func() int { return 1 }, func(t testTeam, id int) testTeam {
+			t.ID = id
+			return t
+		})
+		_, err := store.Create(ctx, testTeam{Name: "foo"})
+		require.NoError(t, err)
+
+		_, err = store.Create(ctx, testTeam{Name: "bar"})
+		require.True(t, errors.Is(err, ErrConflict))
+	})
+}
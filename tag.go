@@ -0,0 +1,118 @@
+package limi
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sanekee/limi/internal/limi"
+)
+
+const (
+	tagPath        = "path"
+	tagAccept      = "accept"
+	tagContentType = "contenttype"
+	tagHeader      = "header"
+	tagQuery       = "query"
+	tagScheme      = "scheme"
+	tagVersion     = "version"
+	tagResource    = "resource"
+	tagID          = "id"
+	tagBodyLimit   = "bodylimit"
+	tagTimeout     = "timeout"
+	tagRateLimit   = "ratelimit"
+)
+
+// tagKeys is the list of options supported in the handler's limi struct tag.
+var tagKeys = map[string]struct{}{
+	tagPath:        {},
+	tagAccept:      {},
+	tagContentType: {},
+	tagHeader:      {},
+	tagQuery:       {},
+	tagScheme:      {},
+	tagVersion:     {},
+	tagResource:    {},
+	tagID:          {},
+	tagBodyLimit:   {},
+	tagTimeout:     {},
+	tagRateLimit:   {},
+}
+
+// tagFlags is the list of options without values.
+var tagFlags = map[string]struct{}{
+	tagResource: {},
+}
+
+// handlerTag is the options of the handler's limi struct tag,
+// e.g. `limi:"path=/foo,/bar,accept=application/json"` has the option path = [/foo, /bar] and accept = [application/json].
+type handlerTag map[string][]string
+
+// getHandlerTag returns the options of the first limi struct tag in the handler.
+func getHandlerTag(t reflectTyper) handlerTag {
+	for i := 0; i < t.NumField(); i++ {
+		if tag := t.Field(i).Tag.Get("limi"); tag != "" {
+			return parseHandlerTag(tag)
+		}
+	}
+	return nil
+}
+
+// parseHandlerTag parses the limi struct tag into options.
+// Comma separated values without a supported option key are appended to the previous option.
+func parseHandlerTag(tag string) handlerTag {
+	ht := handlerTag{}
+
+	var key string
+	for _, s := range limi.SplitEscape(tag, ',') {
+		s = strings.TrimSpace(s)
+		if _, ok := tagFlags[s]; ok {
+			key = ""
+			ht[s] = nil
+			continue
+		}
+
+		if k, v, ok := strings.Cut(s, "="); ok {
+			if _, ok := tagKeys[strings.TrimSpace(k)]; ok {
+				key = strings.TrimSpace(k)
+				s = strings.TrimSpace(v)
+			}
+		}
+
+		if key == "" {
+			continue
+		}
+		ht[key] = append(ht[key], s)
+	}
+	return ht
+}
+
+// metadataFromTag returns the route metadata declared in the handler's limi struct tag.
+//   - `bodylimit=10MB` - MetadataBodyLimit, the request body size limit.
+//   - `timeout=2s` - MetadataTimeout, the request timeout.
+//   - `ratelimit=100/1m` - MetadataRateLimit, the rate limit of the requests.
+func metadataFromTag(ht handlerTag) (Metadata, error) {
+	md := Metadata{}
+	if v := ht[tagBodyLimit]; len(v) > 0 {
+		n, err := limi.ParseByteSize(v[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s tag %w", tagBodyLimit, err)
+		}
+		md[MetadataBodyLimit] = n
+	}
+	if v := ht[tagTimeout]; len(v) > 0 {
+		d, err := time.ParseDuration(v[0])
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid %s tag %q %w", tagTimeout, v[0], limi.ErrInvalidInput)
+		}
+		md[MetadataTimeout] = d
+	}
+	if v := ht[tagRateLimit]; len(v) > 0 {
+		r, err := limi.ParseRate(v[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s tag %q %w", tagRateLimit, v[0], limi.ErrInvalidInput)
+		}
+		md[MetadataRateLimit] = r
+	}
+	return md, nil
+}
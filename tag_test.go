@@ -0,0 +1,85 @@
+package limi
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sanekee/limi/internal/limi"
+	"github.com/sanekee/limi/internal/testing/require"
+)
+
+func TestParseHandlerTag(t *testing.T) {
+	type test struct {
+		testName string
+		tag      string
+		expected handlerTag
+	}
+
+	tests := []test{
+		{testName: "path", tag: "path=/foo", expected: handlerTag{"path": {"/foo"}}},
+		{testName: "multiple paths", tag: "path=/foo, ./bar", expected: handlerTag{"path": {"/foo", "./bar"}}},
+		{testName: "escaped comma", tag: `path=/foo\,bar`, expected: handlerTag{"path": {"/foo,bar"}}},
+		{
+			testName: "multiple options",
+			tag:      "path=/foo,/bar,accept=application/json,application/xml,header=X-Version=2,X-Api-Key,scheme=https",
+			expected: handlerTag{
+				"path":   {"/foo", "/bar"},
+				"accept": {"application/json", "application/xml"},
+				"header": {"X-Version=2", "X-Api-Key"},
+				"scheme": {"https"},
+			},
+		},
+		{testName: "unknown option", tag: "foo=bar", expected: handlerTag{}},
+		{testName: "query with value", tag: "query=format=csv", expected: handlerTag{"query": {"format=csv"}}},
+		{testName: "flag", tag: "path=/teams,resource,id={teamId}", expected: handlerTag{"path": {"/teams"}, "resource": nil, "id": {"{teamId}"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			require.Equal(t, tt.expected, parseHandlerTag(tt.tag))
+		})
+	}
+}
+
+type testUploadHandler struct {
+	Tag struct{} `limi:"path=/upload,bodylimit=10MB"`
+}
+
+func (testUploadHandler) Post(w http.ResponseWriter, req *http.Request) {
+	v, _ := GetRouteMetadata(req.Context(), MetadataBodyLimit)
+	md, _ := GetRouteMetadata(req.Context(), "auth")
+	fmt.Fprint(w, v, " ", md)
+}
+
+type testInvalidUploadHandler struct {
+	Tag struct{} `limi:"path=/upload,bodylimit=ten"`
+}
+
+func (testInvalidUploadHandler) Post(w http.ResponseWriter, req *http.Request) {}
+
+func TestMetadataFromTag(t *testing.T) {
+	md, err := metadataFromTag(parseHandlerTag("path=/upload,bodylimit=1KB,timeout=2s,ratelimit=100/1m"))
+	require.NoError(t, err)
+	require.Equal(t, Metadata{
+		MetadataBodyLimit: int64(1024),
+		MetadataTimeout:   2 * time.Second,
+		MetadataRateLimit: limi.Rate{Limit: 100, Period: time.Minute},
+	}, md)
+
+	for _, tag := range []string{"bodylimit=1.5KB", "timeout=2", "timeout=-1s", "ratelimit=100", "ratelimit=0/s"} {
+		_, err = metadataFromTag(parseHandlerTag(tag))
+		require.Error(t, err)
+	}
+
+	r, err := NewRouter("/")
+	require.NoError(t, err)
+	require.NoError(t, r.With().WithMetadata(Metadata{"auth": "admin", MetadataBodyLimit: 1}).AddHandler(testUploadHandler{}))
+	require.Error(t, r.AddHandler(testInvalidUploadHandler{}))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/upload", nil))
+	require.Equal(t, "10485760 admin", rec.Body.String())
+}
@@ -0,0 +1,317 @@
+package limi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sanekee/limi/internal/limi"
+)
+
+// VersionStrategy is the strategy resolving the requested API version.
+type VersionStrategy int
+
+const (
+	// VersionPath resolves the version from the path segment following the router's path, e.g. `/v2/items`.
+	VersionPath VersionStrategy = iota
+	// VersionHeader resolves the version from the request header, e.g. `Api-Version: v2`.
+	VersionHeader
+	// VersionAccept resolves the version from the Accept media type,
+	// e.g. `application/vnd.example.v2+json` or `application/json; version=2`.
+	VersionAccept
+)
+
+const defaultVersionHeader = "Api-Version"
+
+// Deprecation is the deprecation policy of an API version.
+type Deprecation struct {
+	// Date is the date the version is deprecated, sent in the `Deprecation` header.
+	Date time.Time
+	// Sunset is the date the version will be retired, sent in the `Sunset` header.
+	Sunset time.Time
+	// Link is the url of the deprecation documentation, sent in the `Link` header.
+	Link string
+}
+
+// Versioning is the API versioning of a router.
+//
+// Handlers are registered under multiple versions with the `version` struct tag (i.e. `limi:"path=/items,version=v1|v2"`) or Versions.
+// The request is handled by the handler with the nearest version lower or equal to the requested version,
+// the highest version is used when no version is requested and no default version is set.
+type Versioning struct {
+	// Strategy is the strategy resolving the requested version.
+	Strategy VersionStrategy
+	// Header is the request header of the VersionHeader strategy, default is `Api-Version`.
+	Header string
+	// Default is the version used when no version is requested.
+	Default string
+	// Deprecations is the deprecation policy of the deprecated versions.
+	Deprecations map[string]Deprecation
+}
+
+// WithVersioning set the router's API versioning.
+func WithVersioning(v Versioning) RouterOptions {
+	return func(r *Router) error {
+		if v.Strategy < VersionPath || v.Strategy > VersionAccept {
+			return fmt.Errorf("unsupported version strategy %d %w", v.Strategy, limi.ErrInvalidInput)
+		}
+		if v.Header == "" {
+			v.Header = defaultVersionHeader
+		}
+
+		deprecations := make(map[string]Deprecation, len(v.Deprecations))
+		for version, d := range v.Deprecations {
+			deprecations[normalizeVersion(version)] = d
+		}
+		v.Deprecations = deprecations
+
+		r.versioning = &v
+		return nil
+	}
+}
+
+// APIVersion returns the API version of the request handled, i.e. the handler's version nearest to the requested version.
+func APIVersion(ctx context.Context) string {
+	return limi.GetAPIVersion(ctx)
+}
+
+// stripVersion returns path without the version segment following the router path prefix with the VersionPath strategy,
+// with the index of the removed segment. The requested version is set in the context.
+func (v *Versioning) stripVersion(ctx context.Context, prefix string, path string) (string, int, string) {
+	if v == nil || v.Strategy != VersionPath {
+		return path, -1, ""
+	}
+
+	idx := len(prefix)
+	if !strings.HasPrefix(path, prefix+"/") {
+		return path, -1, ""
+	}
+
+	seg, _, _ := strings.Cut(path[idx+1:], "/")
+	if !isVersion(seg) {
+		return path, -1, ""
+	}
+
+	limi.SetAPIVersion(ctx, seg)
+	stripped := path[:idx] + path[idx+1+len(seg):]
+	if stripped == "" {
+		stripped = "/"
+	}
+	return stripped, idx, "/" + seg
+}
+
+// restoreVersion returns path with the version segment removed by stripVersion.
+func restoreVersion(path string, idx int, seg string) string {
+	if idx < 0 || idx > len(path) {
+		return path
+	}
+	return path[:idx] + seg + path[idx:]
+}
+
+// requestedVersion returns the requested version, or the default version when no version is requested.
+func (v *Versioning) requestedVersion(req *http.Request) string {
+	if v == nil {
+		return ""
+	}
+
+	var version string
+	switch v.Strategy {
+	case VersionPath:
+		version = limi.GetAPIVersion(req.Context())
+	case VersionHeader:
+		version = strings.TrimSpace(req.Header.Get(v.Header))
+	case VersionAccept:
+		version = acceptVersion(strings.Join(req.Header.Values("Accept"), ","))
+	}
+
+	if version == "" {
+		return v.Default
+	}
+	return version
+}
+
+// setHeaders sets the Vary header of the version strategy and deprecation headers of version.
+func (v *Versioning) setHeaders(w http.ResponseWriter, version string) {
+	if v == nil {
+		return
+	}
+
+	switch v.Strategy {
+	case VersionHeader:
+		w.Header().Add("Vary", v.Header)
+	case VersionAccept:
+		w.Header().Add("Vary", "Accept")
+	}
+
+	d, ok := v.Deprecations[normalizeVersion(version)]
+	if !ok {
+		return
+	}
+
+	// RFC 9745
+	if !d.Date.IsZero() {
+		w.Header().Set("Deprecation", "@"+strconv.FormatInt(d.Date.Unix(), 10))
+	}
+	// RFC 8594
+	if !d.Sunset.IsZero() {
+		w.Header().Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if d.Link != "" {
+		w.Header().Add("Link", "<"+d.Link+`>; rel="deprecation"; type="text/html"`)
+	}
+}
+
+// selectVersion returns the highest version in versions lower or equal to the requested version.
+// The highest version is returned when no version is requested.
+func selectVersion(versions []string, requested string) (string, bool) {
+	var ret string
+	var found bool
+	for _, v := range versions {
+		if requested != "" && compareVersions(v, requested) > 0 {
+			continue
+		}
+		if !found || compareVersions(v, ret) > 0 {
+			ret = v
+			found = true
+		}
+	}
+	return ret, found
+}
+
+// hasSharedVersion returns true when versions1 and versions2 have a common version.
+func hasSharedVersion(versions1, versions2 []string) bool {
+	for _, v1 := range versions1 {
+		for _, v2 := range versions2 {
+			if compareVersions(v1, v2) == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// compareVersions compares two versions, e.g. `v1` < `v1.1` < `v2` < `v10`.
+// Versions are compared by the dot separated parts numerically, non numeric parts are compared lexically.
+func compareVersions(v1, v2 string) int {
+	parts1 := strings.Split(normalizeVersion(v1), ".")
+	parts2 := strings.Split(normalizeVersion(v2), ".")
+
+	for i := 0; i < len(parts1) || i < len(parts2); i++ {
+		var p1, p2 string
+		if i < len(parts1) {
+			p1 = parts1[i]
+		}
+		if i < len(parts2) {
+			p2 = parts2[i]
+		}
+
+		n1, err1 := strconv.Atoi(zeroIfEmpty(p1))
+		n2, err2 := strconv.Atoi(zeroIfEmpty(p2))
+		if err1 == nil && err2 == nil {
+			if n1 != n2 {
+				if n1 < n2 {
+					return -1
+				}
+				return 1
+			}
+			continue
+		}
+
+		if c := strings.Compare(p1, p2); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// normalizeVersion returns the version in lower case without the `v` prefix.
+func normalizeVersion(version string) string {
+	version = strings.ToLower(strings.TrimSpace(version))
+	if isVersion(version) {
+		return version[1:]
+	}
+	return version
+}
+
+// isVersion returns true when str is a version with `v` prefix, e.g. `v2`, `v1.1`.
+func isVersion(str string) bool {
+	if len(str) < 2 || (str[0] != 'v' && str[0] != 'V') {
+		return false
+	}
+	for i := 1; i < len(str); i++ {
+		if (str[i] < '0' || str[i] > '9') && str[i] != '.' {
+			return false
+		}
+	}
+	return str[1] != '.'
+}
+
+// acceptVersion returns the version in the most preferred media type of the Accept header,
+// from the `version` parameter or the vendor media type suffix (i.e. `application/vnd.example.v2+json`).
+func acceptVersion(accept string) string {
+	for _, mr := range limi.ParseAccept(accept) {
+		if mr.Q == 0 {
+			continue
+		}
+		if v, ok := mr.Params["version"]; ok {
+			return v
+		}
+
+		subtype, _, _ := strings.Cut(mr.Subtype, "+")
+		if v := subtypeVersion(subtype); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// subtypeVersion returns the trailing `.v<digits>(.<digits>)*` version of a vendor media subtype,
+// e.g. `v1.1` of `vnd.example.v1.1`.
+func subtypeVersion(subtype string) string {
+	parts := strings.Split(subtype, ".")
+	i := len(parts) - 1
+	for i > 0 && isDigits(parts[i]) {
+		i--
+	}
+	if i == 0 || len(parts[i]) < 2 || (parts[i][0] != 'v' && parts[i][0] != 'V') || !isDigits(parts[i][1:]) {
+		return ""
+	}
+	return strings.Join(parts[i:], ".")
+}
+
+// isDigits returns true when str is a non empty string of decimal digits.
+func isDigits(str string) bool {
+	if str == "" {
+		return false
+	}
+	for i := 0; i < len(str); i++ {
+		if str[i] < '0' || str[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// versionsFromTag returns the versions declared in the handler's limi struct tag, e.g. `version=v1|v2`.
+func versionsFromTag(ht handlerTag) []string {
+	var versions []string
+	for _, v := range ht[tagVersion] {
+		for _, s := range strings.Split(v, "|") {
+			if s = strings.TrimSpace(s); s != "" {
+				versions = append(versions, s)
+			}
+		}
+	}
+	return versions
+}
+
+// zeroIfEmpty returns "0" when str is empty.
+func zeroIfEmpty(str string) string {
+	if str == "" {
+		return "0"
+	}
+	return str
+}
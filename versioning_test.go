@@ -0,0 +1,218 @@
+package limi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sanekee/limi/internal/testing/require"
+)
+
+func TestCompareVersions(t *testing.T) {
+	require.Equal(t, 0, compareVersions("v1", "1"))
+	require.Equal(t, 0, compareVersions("V1", "v1.0"))
+	require.Equal(t, -1, compareVersions("v1", "v1.1"))
+	require.Equal(t, -1, compareVersions("v2", "v10"))
+	require.Equal(t, 1, compareVersions("v2.1", "v2"))
+	require.Equal(t, -1, compareVersions("2024-01-01", "2024-06-01"))
+}
+
+func TestSelectVersion(t *testing.T) {
+	versions := []string{"v1", "v3", "v2"}
+
+	v, ok := selectVersion(versions, "v2")
+	require.True(t, ok)
+	require.Equal(t, "v2", v)
+
+	v, ok = selectVersion(versions, "v5")
+	require.True(t, ok)
+	require.Equal(t, "v3", v)
+
+	v, ok = selectVersion(versions, "")
+	require.True(t, ok)
+	require.Equal(t, "v3", v)
+
+	_, ok = selectVersion(versions, "v0")
+	require.False(t, ok)
+}
+
+func TestAcceptVersion(t *testing.T) {
+	require.Equal(t, "v2", acceptVersion("application/vnd.example.v2+json"))
+	require.Equal(t, "2", acceptVersion("application/json; version=2"))
+	require.Equal(t, "v3", acceptVersion("application/vnd.example.v2+json;q=0.5, application/vnd.example.v3+json"))
+	require.Equal(t, "", acceptVersion("application/json"))
+	require.Equal(t, "", acceptVersion(""))
+
+	// dotted versions
+	require.Equal(t, "v1.1", acceptVersion("application/vnd.x.v1.1+json"))
+	require.Equal(t, "v2.0.1", acceptVersion("application/vnd.example.v2.0.1+json"))
+	require.Equal(t, "", acceptVersion("application/vnd.example.1.1+json"))
+	require.Equal(t, "", acceptVersion("application/v1.1+json"))
+}
+
+type testVersionedItems struct {
+	_ struct{} `limi:"path=/items,version=v1|v2"`
+}
+
+func (t testVersionedItems) Get(w http.ResponseWriter, req *http.Request) {
+	w.Write([]byte("items " + APIVersion(req.Context()))) // nolint:errcheck
+}
+
+type testVersionedItemsV3 struct {
+	_ struct{} `limi:"path=/items,version=v3"`
+}
+
+func (t testVersionedItemsV3) Get(w http.ResponseWriter, req *http.Request) {
+	w.Write([]byte("items v3 " + APIVersion(req.Context()))) // nolint:errcheck
+}
+
+func TestVersioning(t *testing.T) {
+	serve := func(h http.Handler, url string, headers map[string]string) *http.Response {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Result()
+	}
+
+	readBody := func(t *testing.T, res *http.Response) string {
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	t.Run("path", func(t *testing.T) {
+		r, err := NewRouter("/api", WithVersioning(Versioning{Strategy: VersionPath}))
+		require.NoError(t, err)
+
+		err = r.AddHandlers([]Handler{testVersionedItems{}, testVersionedItemsV3{}})
+		require.NoError(t, err)
+
+		type test struct {
+			url    string
+			status int
+			body   string
+		}
+
+		tests := []test{
+			{url: "/api/v1/items", status: http.StatusOK, body: "items v1"},
+			{url: "/api/v2/items", status: http.StatusOK, body: "items v2"},
+			{url: "/api/v3/items", status: http.StatusOK, body: "items v3 v3"},
+			{url: "/api/v5/items", status: http.StatusOK, body: "items v3 v3"}, // nearest lower version
+			{url: "/api/items", status: http.StatusOK, body: "items v3 v3"},    // latest version
+			{url: "/api/v0/items", status: http.StatusNotFound},
+			{url: "/v1/api/items", status: http.StatusNotFound},
+		}
+
+		for _, tt := range tests {
+			res := serve(r, tt.url, nil)
+			require.Equal(t, tt.status, res.StatusCode)
+			if tt.body != "" {
+				require.Equal(t, tt.body, readBody(t, res))
+			}
+		}
+	})
+
+	t.Run("path with mux", func(t *testing.T) {
+		m := NewMux()
+		r, err := m.AddRouter("/", WithVersioning(Versioning{Strategy: VersionPath}))
+		require.NoError(t, err)
+
+		err = r.AddHandler(testVersionedItems{})
+		require.NoError(t, err)
+
+		res := serve(m, "/v1/items", nil)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.Equal(t, "items v1", readBody(t, res))
+	})
+
+	t.Run("header", func(t *testing.T) {
+		deprecated := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		sunset := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		r, err := NewRouter("/", WithVersioning(Versioning{
+			Strategy: VersionHeader,
+			Default:  "v2",
+			Deprecations: map[string]Deprecation{
+				"v1": {Date: deprecated, Sunset: sunset, Link: "https://example.com/deprecation"},
+			},
+		}))
+		require.NoError(t, err)
+
+		err = r.AddHandlers([]Handler{testVersionedItems{}, testVersionedItemsV3{}})
+		require.NoError(t, err)
+
+		res := serve(r, "/items", map[string]string{"Api-Version": "1"})
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.Equal(t, "items v1", readBody(t, res))
+		require.Equal(t, "@1704067200", res.Header.Get("Deprecation"))
+		require.Equal(t, "Wed, 01 Jan 2025 00:00:00 GMT", res.Header.Get("Sunset"))
+		require.Equal(t, `<https://example.com/deprecation>; rel="deprecation"; type="text/html"`, res.Header.Get("Link"))
+		require.Equal(t, "Api-Version", res.Header.Get("Vary"))
+
+		res = serve(r, "/items", nil)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.Equal(t, "items v2", readBody(t, res))
+		require.Empty(t, res.Header.Get("Deprecation"))
+	})
+
+	t.Run("accept", func(t *testing.T) {
+		r, err := NewRouter("/", WithVersioning(Versioning{Strategy: VersionAccept}))
+		require.NoError(t, err)
+
+		err = r.AddHandlers([]Handler{testVersionedItems{}, testVersionedItemsV3{}})
+		require.NoError(t, err)
+
+		res := serve(r, "/items", map[string]string{"Accept": "application/vnd.example.v2+json"})
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.Equal(t, "items v2", readBody(t, res))
+		require.Equal(t, "Accept", res.Header.Get("Vary"))
+
+		res = serve(r, "/items", map[string]string{"Accept": "application/vnd.example.v2.1+json"})
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.Equal(t, "items v2", readBody(t, res))
+
+		res = serve(r, "/items", map[string]string{"Accept": "application/vnd.example.v3.0+json"})
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.Equal(t, "items v3 v3", readBody(t, res))
+	})
+
+	t.Run("versions group", func(t *testing.T) {
+		r, err := NewRouter("/", WithVersioning(Versioning{Strategy: VersionPath}))
+		require.NoError(t, err)
+
+		fn := func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte(APIVersion(req.Context()))) // nolint:errcheck
+		}
+
+		err = r.Versions("v1").Get("/foo", fn)
+		require.NoError(t, err)
+
+		err = r.Versions("v2").Get("/foo", fn)
+		require.NoError(t, err)
+
+		err = r.Versions("v2", "v3").Get("/foo", fn)
+		require.Error(t, err)
+
+		// unversioned handler
+		err = r.Get("/foo", fn)
+		require.NoError(t, err)
+
+		res := serve(r, "/v1.5/foo", nil)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.Equal(t, "v1", readBody(t, res))
+
+		res = serve(r, "/v0/foo", nil)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.Equal(t, "v0", readBody(t, res))
+	})
+
+	t.Run("invalid strategy", func(t *testing.T) {
+		_, err := NewRouter("/", WithVersioning(Versioning{Strategy: VersionStrategy(10)}))
+		require.Error(t, err)
+	})
+}